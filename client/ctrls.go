@@ -1,12 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	crypto "github.com/libp2p/go-libp2p-crypto"
 	"github.com/mragiadakos/tendervoting/server/ctrls"
@@ -44,6 +48,123 @@ var GenerateKeyCommand = cli.Command{
 	},
 }
 
+var InvitationsCommand = cli.Command{
+	Name:    "invitations",
+	Aliases: []string{"inv"},
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "number",
+			Usage: "the number of the invitations",
+		},
+		cli.StringFlag{
+			Name:  "filename",
+			Usage: "the filename that the codes will be saved, one for each line",
+		},
+	},
+	Usage: "generate the invitations' codes for the voters, so they are mailed on paper",
+	Action: func(c *cli.Context) error {
+		number := c.Int("number")
+		if number <= 0 {
+			return errors.New("Error: number should be positive")
+		}
+		filename := c.String("filename")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		codes := []string{}
+		for i := 0; i < number; i++ {
+			code, err := invitationCode()
+			if err != nil {
+				return errors.New("Error: " + err.Error())
+			}
+			codes = append(codes, code)
+		}
+		err := ioutil.WriteFile(filename, []byte(strings.Join(codes, "\n")+"\n"), 0600)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		fmt.Println("The invitations saved in", filename)
+		return nil
+	},
+}
+
+var RedeemInvitationCommand = cli.Command{
+	Name:    "redeem",
+	Aliases: []string{"rdm"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the voter's new key",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+		cli.StringFlag{
+			Name:  "code",
+			Usage: "the invitation's code",
+		},
+	},
+	Usage: "commit to the invitation's code and redeem it, so the key becomes a voter of the draft election",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		rdd := ctrls.RedeemInvitationDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		rdd.From = hex.EncodeToString(pubB)
+		rdd.ElectionID = c.String("election")
+		if len(rdd.ElectionID) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		rdd.Code = strings.ToUpper(strings.TrimSpace(c.String("code")))
+		if len(rdd.Code) == 0 {
+			return errors.New("Error: code is missing")
+		}
+
+		// the commitment is committed in a block before the code is shown in the mempool
+		cdd := ctrls.CommitInvitationDeliveryData{From: rdd.From, ElectionID: rdd.ElectionID}
+		cdd.Commitment = ctrls.InvitationCommitmentHash(rdd.Code, rdd.From)
+		b, _ := json.Marshal(cdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = cdd
+		tvd.Type = ctrls.COMMIT_INVITATION
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The commitment to the invitation submitted")
+
+		b, _ = json.Marshal(rdd)
+		sigB, err = priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd = ctrls.TVDelivery{}
+		tvd.Data = rdd
+		tvd.Type = ctrls.REDEEM_INVITATION
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The invitation redeemed")
+		return nil
+	},
+}
+
 var CreateElectionCommand = cli.Command{
 	Name:    "create-election",
 	Aliases: []string{"ce"},
@@ -56,6 +177,55 @@ var CreateElectionCommand = cli.Command{
 			Name:  "voters",
 			Usage: "the voters' public keys seperated by comma",
 		},
+		cli.StringFlag{
+			Name:  "voters-file",
+			Usage: "the filename of the voters, one public key for each line, and only their Merkle root is submitted",
+		},
+		cli.StringFlag{
+			Name:  "trustees",
+			Usage: "the trustees for encrypted polls, as the public key and the exchange key from the trustee-keys command, like <public key>:<exchange key>, seperated by comma",
+		},
+		cli.IntFlag{
+			Name:  "threshold",
+			Usage: "the number of trustees that are needed to decrypt the results",
+		},
+		cli.BoolFlag{
+			Name:  "anonymous",
+			Usage: "the voters vote with ring signatures, so the votes are not linked to the voters",
+		},
+		cli.StringFlag{
+			Name:  "credentials",
+			Usage: "the filename of the credential key from the credential-key command, for voting with credentials",
+		},
+		cli.BoolFlag{
+			Name:  "draft",
+			Usage: "the election starts as a draft, so its voters can change until it opens",
+		},
+		cli.StringFlag{
+			Name:  "name",
+			Usage: "the election's name",
+		},
+		cli.StringFlag{
+			Name:  "description",
+			Usage: "the election's description",
+		},
+		cli.StringFlag{
+			Name:  "jurisdiction",
+			Usage: "the election's jurisdiction",
+		},
+		cli.StringFlag{
+			Name:  "invitations-file",
+			Usage: "the filename of the invitations' codes from the invitations command, and only their hashes are submitted",
+		},
+		cli.StringFlag{
+			Name:  "auditors",
+			Usage: "the auditors' public keys seperated by comma, that can override the embargo of the polls' results",
+		},
+		cli.IntFlag{
+			Name:  "initiative-share",
+			Usage: "the percentage of the voters that should support a voter's initiative, so it becomes a poll, and zero for no initiatives",
+		},
+		groupsFlag,
 	},
 	Usage: "create the election and adding the voters",
 	Action: func(c *cli.Context) error {
@@ -68,12 +238,77 @@ var CreateElectionCommand = cli.Command{
 			return errors.New("Error: " + err.Error())
 		}
 		strVoters := c.String("voters")
-		voters := strings.Split(strVoters, ",")
+		voters := parseList(strVoters)
 		edd := ctrls.ElectionDeliveryData{}
 		pubB, _ := priv.GetPublic().Bytes()
 		edd.From = hex.EncodeToString(pubB)
 		edd.ID = uuid.NewV4().String()
 		edd.Voters = voters
+		votersFilename := c.String("voters-file")
+		if len(votersFilename) > 0 {
+			if len(strVoters) > 0 {
+				return errors.New("Error: use the voters or the voters' file, but not both")
+			}
+			votersList, err := fileVoters(votersFilename)
+			if err != nil {
+				return err
+			}
+			mt, err := ctrls.NewMerkleTree(votersList)
+			if err != nil {
+				return errors.New("Error: " + err.Error())
+			}
+			edd.Voters = nil
+			edd.VotersRoot = mt.Root()
+			edd.VotersCount = len(votersList)
+			fmt.Println("The voters' root is", edd.VotersRoot)
+		}
+		strTrustees := c.String("trustees")
+		if len(strTrustees) > 0 {
+			trustees := []string{}
+			exchangeKeys := []string{}
+			for _, v := range parseList(strTrustees) {
+				parts := strings.Split(v, ":")
+				if len(parts) != 2 {
+					return errors.New("Error: the trustee " + v + " should have the public key and the exchange key")
+				}
+				trustees = append(trustees, parts[0])
+				exchangeKeys = append(exchangeKeys, parts[1])
+			}
+			edd.Encryption, err = ctrls.NewElectionEncryption(trustees, exchangeKeys, c.Int("threshold"))
+			if err != nil {
+				return errors.New("Error: " + err.Error())
+			}
+		}
+		edd.Anonymous = c.Bool("anonymous")
+		edd.Draft = c.Bool("draft")
+		edd.Name = c.String("name")
+		edd.Description = c.String("description")
+		edd.Jurisdiction = c.String("jurisdiction")
+		edd.InitiativeShare = c.Int("initiative-share")
+		edd.Auditors = parseList(c.String("auditors"))
+		invFilename := c.String("invitations-file")
+		if len(invFilename) > 0 {
+			codes, err := fileLines(invFilename)
+			if err != nil {
+				return err
+			}
+			for _, v := range codes {
+				edd.Invitations = append(edd.Invitations, ctrls.InvitationHash(v))
+			}
+		}
+		edd.Groups, err = parseGroups(c.StringSlice("group"))
+		if err != nil {
+			return err
+		}
+		credFilename := c.String("credentials")
+		if len(credFilename) > 0 {
+			cpk, err := fileCredentialKey(credFilename)
+			if err != nil {
+				return err
+			}
+			// only the public part of the credential key is in the election
+			edd.Credentials = &cpk.CredentialKey
+		}
 		b, _ := json.Marshal(edd)
 		sigB, err := priv.Sign(b)
 		if err != nil {
@@ -109,6 +344,18 @@ var AddPollCommand = cli.Command{
 			Name:  "election",
 			Usage: "the election's ID",
 		},
+		cli.Int64Flag{
+			Name:  "end-height",
+			Usage: "the height of the last block that accepts votes for the poll, which is needed for the runoff",
+		},
+		cli.Int64Flag{
+			Name:  "activation-height",
+			Usage: "the height of the block that the scheduled poll starts",
+		},
+		cli.StringFlag{
+			Name:  "activation-time",
+			Usage: "the time that the scheduled poll starts, like 2018-06-01T00:00:00Z",
+		},
 	},
 	Usage: "add the poll to the election",
 	Action: func(c *cli.Context) error {
@@ -137,6 +384,16 @@ var AddPollCommand = cli.Command{
 		pdd.From = hex.EncodeToString(pubB)
 		pdd.PollHash = hash
 		pdd.ElectionID = election
+		pdd.EndHeight = c.Int64("end-height")
+		pdd.ActivationHeight = c.Int64("activation-height")
+		activationTime := c.String("activation-time")
+		if len(activationTime) > 0 {
+			t, err := time.Parse(time.RFC3339, activationTime)
+			if err != nil {
+				return errors.New("Error: the activation time should be like 2018-06-01T00:00:00Z")
+			}
+			pdd.ActivationTime = t.Unix()
+		}
 		b, _ := json.Marshal(pdd)
 		sigB, err := priv.Sign(b)
 		if err != nil {
@@ -151,6 +408,10 @@ var AddPollCommand = cli.Command{
 		if err != nil {
 			return err
 		}
+		if pdd.IsScheduled() {
+			fmt.Println("The poll scheduled")
+			return nil
+		}
 		fmt.Println("The poll submitted")
 		return nil
 	},
@@ -172,6 +433,35 @@ var VoteCommand = cli.Command{
 			Name:  "choice",
 			Usage: "the choice's ID from the poll",
 		},
+		cli.StringSliceFlag{
+			Name:  "answer",
+			Usage: "the answer for a question of the poll, like question=choice1,choice2, and it can be repeated for each question",
+		},
+		cli.StringFlag{
+			Name:  "write-in",
+			Usage: "the candidate that is not in the poll's choices, when the poll allows write-ins",
+		},
+		cli.StringFlag{
+			Name:  "ranking",
+			Usage: "all the choices' IDs from the most to the least preferred, like choice1,choice2, when the poll has the schulze method",
+		},
+		cli.StringSliceFlag{
+			Name:  "score",
+			Usage: "the score for a choice, like choice=score, and it can be repeated for each choice, when the poll has the score method",
+		},
+		cli.StringSliceFlag{
+			Name:  "quadratic-vote",
+			Usage: "the votes for a choice, like choice=votes, and it can be repeated for each choice, when the poll has the quadratic method",
+		},
+		cli.StringFlag{
+			Name:  "projects",
+			Usage: "the approved choices' IDs, like choice1,choice2, when the poll has the budgeting method",
+		},
+		cli.StringFlag{
+			Name:  "voters-file",
+			Usage: "the filename of the election's voters, one public key for each line, for the membership proof",
+		},
+		receiptFlag,
 	},
 	Usage: "vote for a specific poll",
 	Action: func(c *cli.Context) error {
@@ -191,7 +481,23 @@ var VoteCommand = cli.Command{
 		}
 
 		choice := c.String("choice")
-		if len(hash) == 0 {
+		answers, err := parseAnswers(c.StringSlice("answer"))
+		if err != nil {
+			return err
+		}
+		writeIn := ctrls.NormalizeWriteIn(c.String("write-in"))
+		ranking := parseList(c.String("ranking"))
+		scores, err := parseChoiceNumbers("score", c.StringSlice("score"))
+		if err != nil {
+			return err
+		}
+		quadraticVotes, err := parseChoiceNumbers("quadratic vote", c.StringSlice("quadratic-vote"))
+		if err != nil {
+			return err
+		}
+		projects := parseList(c.String("projects"))
+		if len(choice) == 0 && len(answers) == 0 && len(writeIn) == 0 && len(ranking) == 0 && len(scores) == 0 &&
+			len(quadraticVotes) == 0 && len(projects) == 0 {
 			return errors.New("Error: choice is missing")
 		}
 
@@ -200,6 +506,16 @@ var VoteCommand = cli.Command{
 		vdd.From = hex.EncodeToString(pubB)
 		vdd.PollHash = hash
 		vdd.Choice = choice
+		vdd.Answers = answers
+		vdd.WriteIn = writeIn
+		vdd.Ranking = ranking
+		vdd.Scores = scores
+		vdd.QuadraticVotes = quadraticVotes
+		vdd.Projects = projects
+		vdd.Proof, err = fileVoterProof(c.String("voters-file"), vdd.From)
+		if err != nil {
+			return err
+		}
 		b, _ := json.Marshal(vdd)
 		sigB, err := priv.Sign(b)
 		if err != nil {
@@ -210,118 +526,1974 @@ var VoteCommand = cli.Command{
 		tvd.Type = ctrls.VOTE
 		tvd.Signature = sigB
 		b, _ = json.Marshal(tvd)
-		_, err = deliver(b)
+		dr, err := deliver(b)
 		if err != nil {
 			return err
 		}
 		fmt.Println("The vote submitted")
-		return nil
+		return saveReceipt(c.String("receipt"), dr, b)
 
 	},
 }
 
-var QueryElectionsCommand = cli.Command{
-	Name:    "elections",
-	Aliases: []string{"e"},
-	Usage:   "list the election's IDs",
+var TrusteeKeysCommand = cli.Command{
+	Name:    "trustee-keys",
+	Aliases: []string{"tk"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "filename",
+			Usage: "the filename that the trustee's exchange key will be saved",
+		},
+	},
+	Usage: "generate the trustee's exchange key, that the other trustees encrypt the shares of the election's key with",
 	Action: func(c *cli.Context) error {
-		value, err := query("/elections", nil)
+		filename := c.String("filename")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		ek, err := ctrls.NewExchangeKey()
 		if err != nil {
-			return err
+			return errors.New("Error: " + err.Error())
 		}
-		les := ctrls.ListElectionQuery{}
-		json.Unmarshal(value, &les)
-		for _, v := range les {
-			fmt.Println("Election ID:", v.ID)
-			fmt.Println("Latest:", v.Latest)
-			fmt.Println("Number of voters:", v.NumberOfVoters)
-			fmt.Println()
+		b, _ := json.Marshal(ek)
+		err = ioutil.WriteFile(filename, b, 0600)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
 		}
+		fmt.Println("The exchange key saved in", filename)
+		fmt.Println("The exchange key for the gonverment is", ek.PublicKey)
 		return nil
 	},
 }
 
-var QueryLatestElectionCommand = cli.Command{
-	Name:    "latest-election",
-	Aliases: []string{"le"},
-	Usage:   "get the latest election's IDs",
+var DealKeyCommand = cli.Command{
+	Name:    "deal-key",
+	Aliases: []string{"dk"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the trustee's key",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+	},
+	Usage: "deal the trustee's part of the election's key, with the shares encrypted for the trustees",
 	Action: func(c *cli.Context) error {
-		value, err := query("/elections/latest", nil)
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
 		if err != nil {
-			return err
+			return errors.New("Error: " + err.Error())
 		}
-
-		v := ctrls.ItemElectionQuery{}
-		json.Unmarshal(value, &v)
-		fmt.Println("Election ID:", v.ID)
-		fmt.Println("Latest:", v.Latest)
-		fmt.Println("Number of voters:", v.NumberOfVoters)
-		fmt.Println()
-
-		return nil
-	},
-}
-
-var QueryPollsCommand = cli.Command{
-	Name:    "polls",
-	Aliases: []string{"p"},
-	Usage:   "list the poll's hash keys",
-	Action: func(c *cli.Context) error {
-
-		value, err := query("/polls", nil)
+		electionID := c.String("election")
+		if len(electionID) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		enc, err := queryElectionEncryption(electionID)
 		if err != nil {
 			return err
 		}
-
-		pes := ctrls.ListPollQuery{}
-		json.Unmarshal(value, &pes)
-		for _, v := range pes {
-			fmt.Println("Poll's Hash:", v.PollHash)
-			fmt.Println("Latest:", v.Latest)
-			fmt.Println()
+		kdd := ctrls.KeyDealingDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		kdd.From = hex.EncodeToString(pubB)
+		kdd.ElectionID = electionID
+		td, err := ctrls.NewTrusteeDealing(enc)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
 		}
-
-		return nil
-	},
-}
-
-var QueryLatestPollCommand = cli.Command{
-	Name:    "latest-poll",
-	Aliases: []string{"lp"},
-	Usage:   "get the latest poll's hash key",
-	Action: func(c *cli.Context) error {
-		value, err := query("/polls/latest", nil)
+		kdd.Dealing = *td
+		b, _ := json.Marshal(kdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = kdd
+		tvd.Type = ctrls.KEY_DEALING
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
 		if err != nil {
 			return err
 		}
-
-		v := ctrls.ItemPollQuery{}
-		json.Unmarshal(value, &v)
-		fmt.Println("Poll's Hash:", v.PollHash)
-		fmt.Println("Latest:", v.Latest)
-		fmt.Println()
-
+		fmt.Println("The dealing submitted")
 		return nil
 	},
 }
 
-var QueryResultsCommand = cli.Command{
-	Name:    "results",
-	Aliases: []string{"r"},
+var TrusteeShareCommand = cli.Command{
+	Name:    "trustee-share",
+	Aliases: []string{"ts"},
 	Flags: []cli.Flag{
 		cli.StringFlag{
-			Name:  "hash",
-			Usage: "the poll's directory as an IPFS hash",
+			Name:  "key",
+			Usage: "the filename of the trustee's key",
+		},
+		cli.StringFlag{
+			Name:  "exchange",
+			Usage: "the filename of the trustee's exchange key",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+		cli.StringFlag{
+			Name:  "filename",
+			Usage: "the filename that the trustee's share will be saved, when the election's key is finished",
 		},
 	},
-	Usage: "get the latest results based on poll's hash key",
+	Usage: "check the shares from the trustees' dealings and complain for the wrong ones, or save the trustee's share when the election's key is finished",
 	Action: func(c *cli.Context) error {
-		hash := c.String("hash")
-		if len(hash) == 0 {
-			return errors.New("Error: hash is missing")
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
 		}
-
-		b, _ := json.Marshal(ctrls.PollQuery{PollHash: hash})
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		exchangeFilename := c.String("exchange")
+		if len(exchangeFilename) == 0 {
+			return errors.New("Error: exchange is missing")
+		}
+		b, err := ioutil.ReadFile(exchangeFilename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		ek := ctrls.ExchangeKey{}
+		err = json.Unmarshal(b, &ek)
+		if err != nil {
+			return errors.New("Error: json problem with the exchange key " + err.Error())
+		}
+		electionID := c.String("election")
+		if len(electionID) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		shareFilename := c.String("filename")
+		if len(shareFilename) == 0 {
+			return errors.New("Error: filename for the share is missing")
+		}
+
+		enc, err := queryElectionEncryption(electionID)
+		if err != nil {
+			return err
+		}
+		pubB, _ := priv.GetPublic().Bytes()
+		pubHex := hex.EncodeToString(pubB)
+		trustee, ok := enc.GetTrustee(pubHex)
+		if !ok {
+			return errors.New("Error: the key is not a trustee of the election")
+		}
+		share, wrong, err := enc.OpenShares(&ek, trustee.Index)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		if enc.IsKeyFinished() {
+			if len(wrong) > 0 {
+				return errors.New("Error: the election's key is finished with wrong shares from " + strings.Join(wrong, ", "))
+			}
+			err = ctrls.VerifyTrusteeShare(*trustee, *share)
+			if err != nil {
+				return errors.New("Error: " + err.Error())
+			}
+			b, _ = json.Marshal(share)
+			err = ioutil.WriteFile(shareFilename, b, 0600)
+			if err != nil {
+				return errors.New("Error: " + err.Error())
+			}
+			fmt.Println("The share saved in", shareFilename)
+			return nil
+		}
+
+		for _, dealer := range wrong {
+			t, _ := enc.GetTrustee(dealer)
+			key, proof, err := ek.ProveShareKey(t.Dealing.Shares[trustee.Index])
+			if err != nil {
+				return errors.New("Error: " + err.Error())
+			}
+			kcd := ctrls.KeyComplaintDeliveryData{From: pubHex, ElectionID: electionID, Dealer: dealer, Key: key, Proof: proof}
+			b, _ = json.Marshal(kcd)
+			sigB, err := priv.Sign(b)
+			if err != nil {
+				return errors.New("Error: " + err.Error())
+			}
+			tvd := ctrls.TVDelivery{}
+			tvd.Data = kcd
+			tvd.Type = ctrls.KEY_COMPLAINT
+			tvd.Signature = sigB
+			b, _ = json.Marshal(tvd)
+			_, err = deliver(b)
+			if err != nil {
+				return err
+			}
+			fmt.Println("The complaint submitted for the wrong share from", dealer)
+		}
+		if len(wrong) == 0 {
+			fmt.Println("The shares from the dealings are correct.")
+		}
+		if !enc.HasAllDealings() {
+			fmt.Println("Some trustees have not dealt yet, so run the command again after their dealings to check their shares.")
+			return nil
+		}
+		fmt.Println("The complaints are accepted until height", enc.ComplaintDeadline(), "and the first poll after it finishes the election's key.")
+		fmt.Println("Run the command again after the first poll to save the share.")
+		return nil
+	},
+}
+
+func queryElectionEncryption(electionID string) (*ctrls.ElectionEncryption, error) {
+	b, _ := json.Marshal(ctrls.ElectionQuery{ID: electionID})
+	value, err := query("/elections/encryption", b)
+	if err != nil {
+		return nil, err
+	}
+	enc := ctrls.ElectionEncryption{}
+	err = json.Unmarshal(value, &enc)
+	if err != nil {
+		return nil, errors.New("Error: json problem with the election's encryption " + err.Error())
+	}
+	return &enc, nil
+}
+
+var EncryptedVoteCommand = cli.Command{
+	Name:    "encrypted-vote",
+	Aliases: []string{"ev"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+		cli.StringFlag{
+			Name:  "choice",
+			Usage: "the choice's ID from the poll",
+		},
+		cli.StringFlag{
+			Name:  "voters-file",
+			Usage: "the filename of the election's voters, one public key for each line, for the membership proof",
+		},
+		receiptFlag,
+	},
+	Usage: "vote with an encrypted ballot for a specific poll",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+
+		choice := c.String("choice")
+		if len(choice) == 0 {
+			return errors.New("Error: choice is missing")
+		}
+
+		peq, err := queryPollEncryption(hash)
+		if err != nil {
+			return err
+		}
+		choices := []string{}
+		for k := range peq.EncryptedChoices {
+			choices = append(choices, k)
+		}
+
+		evdd := ctrls.EncryptedVoteDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		evdd.From = hex.EncodeToString(pubB)
+		evdd.PollHash = hash
+		eb, err := peq.Encryption.EncryptBallot(ctrls.BallotContext(evdd.From, hash), choices, choice)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		evdd.EncryptedBallot = *eb
+		evdd.Proof, err = fileVoterProof(c.String("voters-file"), evdd.From)
+		if err != nil {
+			return err
+		}
+		b, _ := json.Marshal(evdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = evdd
+		tvd.Type = ctrls.ENCRYPTED_VOTE
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		dr, err := deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The encrypted vote submitted")
+		return saveReceipt(c.String("receipt"), dr, b)
+	},
+}
+
+var receiptFlag = cli.StringFlag{
+	Name:  "receipt",
+	Usage: "the filename that the vote's receipt will be saved, by default it is receipt-<transaction's hash>.json",
+}
+
+var AnonymousVoteCommand = cli.Command{
+	Name:    "anonymous-vote",
+	Aliases: []string{"av"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+		cli.StringFlag{
+			Name:  "choice",
+			Usage: "the choice's ID from the poll",
+		},
+		cli.StringSliceFlag{
+			Name:  "answer",
+			Usage: "the answer for a question of the poll, like question=choice1,choice2, and it can be repeated for each question",
+		},
+		cli.StringFlag{
+			Name:  "write-in",
+			Usage: "the candidate that is not in the poll's choices, when the poll allows write-ins",
+		},
+		cli.StringFlag{
+			Name:  "ranking",
+			Usage: "all the choices' IDs from the most to the least preferred, like choice1,choice2, when the poll has the schulze method",
+		},
+		cli.StringSliceFlag{
+			Name:  "score",
+			Usage: "the score for a choice, like choice=score, and it can be repeated for each choice, when the poll has the score method",
+		},
+		cli.StringSliceFlag{
+			Name:  "quadratic-vote",
+			Usage: "the votes for a choice, like choice=votes, and it can be repeated for each choice, when the poll has the quadratic method",
+		},
+		cli.StringFlag{
+			Name:  "projects",
+			Usage: "the approved choices' IDs, like choice1,choice2, when the poll has the budgeting method",
+		},
+		receiptFlag,
+	},
+	Usage: "vote with a ring signature for a specific poll of an anonymous election",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+
+		choice := c.String("choice")
+		answers, err := parseAnswers(c.StringSlice("answer"))
+		if err != nil {
+			return err
+		}
+		writeIn := ctrls.NormalizeWriteIn(c.String("write-in"))
+		ranking := parseList(c.String("ranking"))
+		scores, err := parseChoiceNumbers("score", c.StringSlice("score"))
+		if err != nil {
+			return err
+		}
+		quadraticVotes, err := parseChoiceNumbers("quadratic vote", c.StringSlice("quadratic-vote"))
+		if err != nil {
+			return err
+		}
+		projects := parseList(c.String("projects"))
+		if len(choice) == 0 && len(answers) == 0 && len(writeIn) == 0 && len(ranking) == 0 && len(scores) == 0 &&
+			len(quadraticVotes) == 0 && len(projects) == 0 {
+			return errors.New("Error: choice is missing")
+		}
+
+		b, _ := json.Marshal(ctrls.PollQuery{PollHash: hash})
+		value, err := query("/polls/ring", b)
+		if err != nil {
+			return err
+		}
+		prq := ctrls.PollRingQuery{}
+		err = json.Unmarshal(value, &prq)
+		if err != nil {
+			return errors.New("Error: json problem with the poll's ring " + err.Error())
+		}
+
+		avdd := ctrls.AnonymousVoteDeliveryData{}
+		avdd.PollHash = hash
+		avdd.Choice = choice
+		avdd.Answers = answers
+		avdd.WriteIn = writeIn
+		avdd.Ranking = ranking
+		avdd.Scores = scores
+		avdd.QuadraticVotes = quadraticVotes
+		avdd.Projects = projects
+		avdd.KeyImage, err = ctrls.RingKeyImage(priv, hash)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		b, _ = json.Marshal(avdd)
+		sigB, err := ctrls.SignRing(priv, prq.Voters, hash, b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = avdd
+		tvd.Type = ctrls.ANONYMOUS_VOTE
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		dr, err := deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The anonymous vote submitted")
+		return saveReceipt(c.String("receipt"), dr, b)
+	},
+}
+
+var DecryptCommand = cli.Command{
+	Name:    "decrypt",
+	Aliases: []string{"dc"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the trustee's key",
+		},
+		cli.StringFlag{
+			Name:  "share",
+			Usage: "the filename of the trustee's share",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+	},
+	Usage: "submit the trustee's decryption for the results of an encrypted poll",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+
+		shareFilename := c.String("share")
+		if len(shareFilename) == 0 {
+			return errors.New("Error: share is missing")
+		}
+		b, err := ioutil.ReadFile(shareFilename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		share := ctrls.TrusteeShare{}
+		err = json.Unmarshal(b, &share)
+		if err != nil {
+			return errors.New("Error: json problem with the share " + err.Error())
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+
+		peq, err := queryPollEncryption(hash)
+		if err != nil {
+			return err
+		}
+
+		ddd := ctrls.DecryptionDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		ddd.From = hex.EncodeToString(pubB)
+		ddd.PollHash = hash
+		ddd.Shares, err = share.DecryptChoices(peq.EncryptedChoices)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		b, _ = json.Marshal(ddd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = ddd
+		tvd.Type = ctrls.DECRYPTION
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The decryption submitted")
+		return nil
+	},
+}
+
+func queryPollEncryption(hash string) (*ctrls.PollEncryptionQuery, error) {
+	b, _ := json.Marshal(ctrls.PollQuery{PollHash: hash})
+	value, err := query("/polls/encryption", b)
+	if err != nil {
+		return nil, err
+	}
+	peq := ctrls.PollEncryptionQuery{}
+	err = json.Unmarshal(value, &peq)
+	if err != nil {
+		return nil, errors.New("Error: json problem with the poll's encryption " + err.Error())
+	}
+	return &peq, nil
+}
+
+var CredentialKeyCommand = cli.Command{
+	Name:    "credential-key",
+	Aliases: []string{"ck"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "filename",
+			Usage: "the filename that the credential key will be saved",
+		},
+	},
+	Usage: "generate the gonverment's key for signing the voters' credentials",
+	Action: func(c *cli.Context) error {
+		filename := c.String("filename")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		cpk, err := ctrls.NewCredentialKey(2048)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		b, _ := json.Marshal(cpk)
+		err = ioutil.WriteFile(filename, b, 0600)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		fmt.Println("The credential key saved in", filename)
+		return nil
+	},
+}
+
+var RequestCredentialCommand = cli.Command{
+	Name:    "request-credential",
+	Aliases: []string{"rc"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+		cli.StringFlag{
+			Name:  "filename",
+			Usage: "the filename that the credential with the new voting key will be saved",
+		},
+		cli.StringFlag{
+			Name:  "voters-file",
+			Usage: "the filename of the election's voters, one public key for each line, for the membership proof",
+		},
+	},
+	Usage: "request from the gonverment a blind signature for a new voting key",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		electionID := c.String("election")
+		if len(electionID) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		credFilename := c.String("filename")
+		if len(credFilename) == 0 {
+			return errors.New("Error: the credential's filename is missing")
+		}
+		pubB, _ := priv.GetPublic().Bytes()
+		csq, err := queryCredential(electionID, hex.EncodeToString(pubB))
+		if err != nil {
+			return err
+		}
+
+		votingKey, _, _ := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+		cj := CredentialJson{}
+		cj.ElectionID = electionID
+		cj.Voter = hex.EncodeToString(pubB)
+		b, _ := votingKey.GetPublic().Bytes()
+		cj.VotingKey.PublicKey = hex.EncodeToString(b)
+		cj.VotingKey.PrivateKey, _ = crypto.MarshalPrivateKey(votingKey)
+		cj.Blinded, cj.BlindingFactor, err = csq.Key.Blind(ctrls.CredentialMessage(electionID, cj.VotingKey.PublicKey))
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		// the credential is saved before the request, so the blinding factor is not lost
+		b, _ = json.Marshal(cj)
+		err = ioutil.WriteFile(credFilename, b, 0600)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+
+		crdd := ctrls.CredentialRequestDeliveryData{}
+		crdd.From = cj.Voter
+		crdd.ElectionID = electionID
+		crdd.Blinded = cj.Blinded
+		crdd.Proof, err = fileVoterProof(c.String("voters-file"), crdd.From)
+		if err != nil {
+			return err
+		}
+		b, _ = json.Marshal(crdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = crdd
+		tvd.Type = ctrls.CREDENTIAL_REQUEST
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The credential's request submitted and the voting key saved in", credFilename)
+		return nil
+	},
+}
+
+var IssueCredentialCommand = cli.Command{
+	Name:    "issue-credential",
+	Aliases: []string{"ic"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "credential-key",
+			Usage: "the filename of the credential key",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+		cli.StringFlag{
+			Name:  "voter",
+			Usage: "the voter's public key",
+		},
+	},
+	Usage: "sign blindly the voter's request for a credential",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		credKeyFilename := c.String("credential-key")
+		if len(credKeyFilename) == 0 {
+			return errors.New("Error: credential key is missing")
+		}
+		cpk, err := fileCredentialKey(credKeyFilename)
+		if err != nil {
+			return err
+		}
+		electionID := c.String("election")
+		if len(electionID) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		voter := c.String("voter")
+		if len(voter) == 0 {
+			return errors.New("Error: voter is missing")
+		}
+		csq, err := queryCredential(electionID, voter)
+		if err != nil {
+			return err
+		}
+		if len(csq.Blinded) == 0 {
+			return errors.New("Error: the voter has not requested a credential")
+		}
+
+		cdd := ctrls.CredentialDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		cdd.From = hex.EncodeToString(pubB)
+		cdd.ElectionID = electionID
+		cdd.Voter = voter
+		cdd.BlindSignature, err = cpk.SignBlinded(csq.Blinded)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		b, _ := json.Marshal(cdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = cdd
+		tvd.Type = ctrls.CREDENTIAL
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The credential issued for the voter", voter)
+		return nil
+	},
+}
+
+var UnblindCredentialCommand = cli.Command{
+	Name:    "unblind",
+	Aliases: []string{"ub"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "credential",
+			Usage: "the filename of the credential from the request-credential command",
+		},
+	},
+	Usage: "unblind the gonverment's signature and save the credential",
+	Action: func(c *cli.Context) error {
+		credFilename := c.String("credential")
+		if len(credFilename) == 0 {
+			return errors.New("Error: credential is missing")
+		}
+		cj, err := fileCredential(credFilename)
+		if err != nil {
+			return err
+		}
+		csq, err := queryCredential(cj.ElectionID, cj.Voter)
+		if err != nil {
+			return err
+		}
+		if len(csq.BlindSignature) == 0 {
+			return errors.New("Error: the gonverment has not signed the credential yet")
+		}
+		if csq.Blinded != cj.Blinded {
+			return errors.New("Error: the blinded voting key is not the same with the request")
+		}
+		cj.Credential, err = csq.Key.Unblind(csq.BlindSignature, cj.BlindingFactor)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		err = csq.Key.VerifyCredential(ctrls.CredentialMessage(cj.ElectionID, cj.VotingKey.PublicKey), cj.Credential)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		b, _ := json.Marshal(cj)
+		err = ioutil.WriteFile(credFilename, b, 0600)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		fmt.Println("The credential unblinded and saved in", credFilename)
+		return nil
+	},
+}
+
+var VoteAnonymouslyCommand = cli.Command{
+	Name:    "vote-anonymously",
+	Aliases: []string{"va"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "credential",
+			Usage: "the filename of the unblinded credential",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+		cli.StringFlag{
+			Name:  "choice",
+			Usage: "the choice's ID from the poll",
+		},
+		cli.StringSliceFlag{
+			Name:  "answer",
+			Usage: "the answer for a question of the poll, like question=choice1,choice2, and it can be repeated for each question",
+		},
+		cli.StringFlag{
+			Name:  "write-in",
+			Usage: "the candidate that is not in the poll's choices, when the poll allows write-ins",
+		},
+		cli.StringFlag{
+			Name:  "ranking",
+			Usage: "all the choices' IDs from the most to the least preferred, like choice1,choice2, when the poll has the schulze method",
+		},
+		cli.StringSliceFlag{
+			Name:  "score",
+			Usage: "the score for a choice, like choice=score, and it can be repeated for each choice, when the poll has the score method",
+		},
+		cli.StringSliceFlag{
+			Name:  "quadratic-vote",
+			Usage: "the votes for a choice, like choice=votes, and it can be repeated for each choice, when the poll has the quadratic method",
+		},
+		cli.StringFlag{
+			Name:  "projects",
+			Usage: "the approved choices' IDs, like choice1,choice2, when the poll has the budgeting method",
+		},
+		receiptFlag,
+	},
+	Usage: "vote with the credential's voting key for a specific poll",
+	Action: func(c *cli.Context) error {
+		credFilename := c.String("credential")
+		if len(credFilename) == 0 {
+			return errors.New("Error: credential is missing")
+		}
+		cj, err := fileCredential(credFilename)
+		if err != nil {
+			return err
+		}
+		if len(cj.Credential) == 0 {
+			return errors.New("Error: the credential is not unblinded")
+		}
+		votingKey, err := crypto.UnmarshalPrivateKey(cj.VotingKey.PrivateKey)
+		if err != nil {
+			return errors.New("Error: private key decoding problem with the voting key " + err.Error())
+		}
+
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+
+		choice := c.String("choice")
+		answers, err := parseAnswers(c.StringSlice("answer"))
+		if err != nil {
+			return err
+		}
+		writeIn := ctrls.NormalizeWriteIn(c.String("write-in"))
+		ranking := parseList(c.String("ranking"))
+		scores, err := parseChoiceNumbers("score", c.StringSlice("score"))
+		if err != nil {
+			return err
+		}
+		quadraticVotes, err := parseChoiceNumbers("quadratic vote", c.StringSlice("quadratic-vote"))
+		if err != nil {
+			return err
+		}
+		projects := parseList(c.String("projects"))
+		if len(choice) == 0 && len(answers) == 0 && len(writeIn) == 0 && len(ranking) == 0 && len(scores) == 0 &&
+			len(quadraticVotes) == 0 && len(projects) == 0 {
+			return errors.New("Error: choice is missing")
+		}
+
+		cvdd := ctrls.CredentialVoteDeliveryData{}
+		cvdd.From = cj.VotingKey.PublicKey
+		cvdd.PollHash = hash
+		cvdd.Choice = choice
+		cvdd.Answers = answers
+		cvdd.WriteIn = writeIn
+		cvdd.Ranking = ranking
+		cvdd.Scores = scores
+		cvdd.QuadraticVotes = quadraticVotes
+		cvdd.Projects = projects
+		cvdd.Credential = cj.Credential
+		b, _ := json.Marshal(cvdd)
+		sigB, err := votingKey.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = cvdd
+		tvd.Type = ctrls.CREDENTIAL_VOTE
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		dr, err := deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The vote with the credential submitted")
+		return saveReceipt(c.String("receipt"), dr, b)
+	},
+}
+
+func queryCredential(electionID, voter string) (*ctrls.CredentialStateQuery, error) {
+	b, _ := json.Marshal(ctrls.CredentialQuery{ElectionID: electionID, Voter: voter})
+	value, err := query("/credentials", b)
+	if err != nil {
+		return nil, err
+	}
+	csq := ctrls.CredentialStateQuery{}
+	err = json.Unmarshal(value, &csq)
+	if err != nil {
+		return nil, errors.New("Error: json problem with the credential " + err.Error())
+	}
+	return &csq, nil
+}
+
+var ElectionStatusCommand = cli.Command{
+	Name:    "election-status",
+	Aliases: []string{"est"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+		cli.StringFlag{
+			Name:  "status",
+			Usage: "the election's next status, which is 'open', 'closed' or 'archived'",
+		},
+	},
+	Usage: "move the election to the next status of its lifecycle",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		electionID := c.String("election")
+		if len(electionID) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		status := c.String("status")
+		if len(status) == 0 {
+			return errors.New("Error: status is missing")
+		}
+		esdd := ctrls.ElectionStatusDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		esdd.From = hex.EncodeToString(pubB)
+		esdd.ElectionID = electionID
+		esdd.Status = ctrls.ElectionStatus(status)
+		b, _ := json.Marshal(esdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = esdd
+		tvd.Type = ctrls.ELECTION_STATUS
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The election is", status)
+		return nil
+	},
+}
+
+var MergeWriteInsCommand = cli.Command{
+	Name:    "merge-write-ins",
+	Aliases: []string{"mwi"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+		cli.StringSliceFlag{
+			Name:  "variant",
+			Usage: "the write-in that will be merged, and it can be repeated for each variant",
+		},
+		cli.StringFlag{
+			Name:  "candidate",
+			Usage: "the official candidate, which is a choice's ID from the poll or a write-in",
+		},
+	},
+	Usage: "merge the variants of a write-in into one candidate, after the poll closes",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+		variants := c.StringSlice("variant")
+		if len(variants) == 0 {
+			return errors.New("Error: variant is missing")
+		}
+		candidate := c.String("candidate")
+		if len(candidate) == 0 {
+			return errors.New("Error: candidate is missing")
+		}
+		mdd := ctrls.MergeWriteInsDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		mdd.From = hex.EncodeToString(pubB)
+		mdd.PollHash = hash
+		for _, v := range variants {
+			mdd.Variants = append(mdd.Variants, ctrls.NormalizeWriteIn(v))
+		}
+		mdd.Candidate = candidate
+		b, _ := json.Marshal(mdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = mdd
+		tvd.Type = ctrls.MERGE_WRITE_INS
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The write-ins merged into", candidate)
+		return nil
+	},
+}
+
+var ProposeInitiativeCommand = cli.Command{
+	Name:    "propose-initiative",
+	Aliases: []string{"pi"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+		cli.StringFlag{
+			Name:  "voters-file",
+			Usage: "the filename of the election's voters, one public key for each line, for the membership proof",
+		},
+	},
+	Usage: "propose the poll as a voter, and it becomes a poll when enough voters support it",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+		election := c.String("election")
+		if len(election) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		idd := ctrls.InitiativeDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		idd.From = hex.EncodeToString(pubB)
+		idd.ElectionID = election
+		idd.PollHash = hash
+		idd.Proof, err = fileVoterProof(c.String("voters-file"), idd.From)
+		if err != nil {
+			return err
+		}
+		b, _ := json.Marshal(idd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = idd
+		tvd.Type = ctrls.INITIATIVE
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The initiative submitted")
+		return nil
+	},
+}
+
+var SupportInitiativeCommand = cli.Command{
+	Name:    "support-initiative",
+	Aliases: []string{"si"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the initiative's poll as an IPFS hash",
+		},
+		cli.StringFlag{
+			Name:  "voters-file",
+			Usage: "the filename of the election's voters, one public key for each line, for the membership proof",
+		},
+	},
+	Usage: "support the voter's initiative",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+		sdd := ctrls.SupportDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		sdd.From = hex.EncodeToString(pubB)
+		sdd.PollHash = hash
+		sdd.Proof, err = fileVoterProof(c.String("voters-file"), sdd.From)
+		if err != nil {
+			return err
+		}
+		b, _ := json.Marshal(sdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = sdd
+		tvd.Type = ctrls.SUPPORT
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The support submitted")
+		return nil
+	},
+}
+
+var RegisterCommand = cli.Command{
+	Name:    "register",
+	Aliases: []string{"reg"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the voter's key",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the draft election's ID",
+		},
+		cli.StringSliceFlag{
+			Name:  "attribute",
+			Usage: "the attribute for the gonverment, like name=value, and it can be repeated for each attribute",
+		},
+	},
+	Usage: "request from the gonverment to be a voter of the draft election",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		election := c.String("election")
+		if len(election) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		rdd := ctrls.RegistrationDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		rdd.From = hex.EncodeToString(pubB)
+		rdd.ElectionID = election
+		rdd.Attributes, err = parseAttributes(c.StringSlice("attribute"))
+		if err != nil {
+			return err
+		}
+		b, _ := json.Marshal(rdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = rdd
+		tvd.Type = ctrls.REGISTRATION
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The registration submitted")
+		return nil
+	},
+}
+
+var registrationFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "key",
+		Usage: "the filename of the key",
+	},
+	cli.StringFlag{
+		Name:  "election",
+		Usage: "the draft election's ID",
+	},
+	cli.StringFlag{
+		Name:  "voter",
+		Usage: "the public key of the voter that requested the registration",
+	},
+}
+
+var ApproveRegistrationCommand = cli.Command{
+	Name:    "approve",
+	Aliases: []string{"apr"},
+	Flags:   registrationFlags,
+	Usage:   "approve the voter's registration, and add the voter to the draft election",
+	Action: func(c *cli.Context) error {
+		err := deliverRegistrationDecision(c, ctrls.APPROVE)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The registration approved")
+		return nil
+	},
+}
+
+var RejectRegistrationCommand = cli.Command{
+	Name:    "reject",
+	Aliases: []string{"rj"},
+	Flags: append(append([]cli.Flag{}, registrationFlags...), cli.StringFlag{
+		Name:  "reason",
+		Usage: "the reason of the rejection",
+	}),
+	Usage: "reject the voter's registration",
+	Action: func(c *cli.Context) error {
+		err := deliverRegistrationDecision(c, ctrls.REJECT)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The registration rejected")
+		return nil
+	},
+}
+
+func deliverRegistrationDecision(c *cli.Context, dt ctrls.DeliveryType) error {
+	filename := c.String("key")
+	if len(filename) == 0 {
+		return errors.New("Error: filename is missing")
+	}
+	priv, err := fileKey(filename)
+	if err != nil {
+		return errors.New("Error: " + err.Error())
+	}
+	rdd := ctrls.RegistrationDecisionDeliveryData{}
+	pubB, _ := priv.GetPublic().Bytes()
+	rdd.From = hex.EncodeToString(pubB)
+	rdd.ElectionID = c.String("election")
+	if len(rdd.ElectionID) == 0 {
+		return errors.New("Error: election is missing")
+	}
+	rdd.Voter = c.String("voter")
+	if len(rdd.Voter) == 0 {
+		return errors.New("Error: voter is missing")
+	}
+	rdd.Reason = c.String("reason")
+	b, _ := json.Marshal(rdd)
+	sigB, err := priv.Sign(b)
+	if err != nil {
+		return errors.New("Error: " + err.Error())
+	}
+	tvd := ctrls.TVDelivery{}
+	tvd.Data = rdd
+	tvd.Type = dt
+	tvd.Signature = sigB
+	b, _ = json.Marshal(tvd)
+	_, err = deliver(b)
+	return err
+}
+
+var ReplaceKeyCommand = cli.Command{
+	Name:    "replace-key",
+	Aliases: []string{"rk"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+		cli.StringFlag{
+			Name:  "old-voter",
+			Usage: "the public key that the voter lost",
+		},
+		cli.StringFlag{
+			Name:  "new-voter",
+			Usage: "the voter's new public key",
+		},
+	},
+	Usage: "replace the key of a voter that lost it, and the new key keeps the votes of the old key",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		rdd := ctrls.ReplaceKeyDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		rdd.From = hex.EncodeToString(pubB)
+		rdd.ElectionID = c.String("election")
+		if len(rdd.ElectionID) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		rdd.OldVoter = c.String("old-voter")
+		if len(rdd.OldVoter) == 0 {
+			return errors.New("Error: old voter is missing")
+		}
+		rdd.NewVoter = c.String("new-voter")
+		if len(rdd.NewVoter) == 0 {
+			return errors.New("Error: new voter is missing")
+		}
+		b, _ := json.Marshal(rdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = rdd
+		tvd.Type = ctrls.REPLACE_KEY
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The voter's key replaced")
+		return nil
+	},
+}
+
+var OverrideEmbargoCommand = cli.Command{
+	Name:    "override-embargo",
+	Aliases: []string{"oe"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the gonverment's or the auditor's key",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+		cli.StringFlag{
+			Name:  "reason",
+			Usage: "the reason that the results are shown before the poll closes",
+		},
+	},
+	Usage: "show the results of the poll under embargo to everyone, and the override is recorded in the chain",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		edd := ctrls.EmbargoOverrideDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		edd.From = hex.EncodeToString(pubB)
+		edd.PollHash = c.String("hash")
+		if len(edd.PollHash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+		edd.Reason = c.String("reason")
+		if len(edd.Reason) == 0 {
+			return errors.New("Error: reason is missing")
+		}
+		b, _ := json.Marshal(edd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = edd
+		tvd.Type = ctrls.EMBARGO_OVERRIDE
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The embargo overridden")
+		return nil
+	},
+}
+
+var pauseFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "key",
+		Usage: "the filename of the key",
+	},
+	cli.StringFlag{
+		Name:  "election",
+		Usage: "the election's ID",
+	},
+	cli.StringFlag{
+		Name:  "hash",
+		Usage: "the poll's directory as an IPFS hash, instead of the election",
+	},
+}
+
+var PauseCommand = cli.Command{
+	Name:    "pause",
+	Aliases: []string{"pa"},
+	Flags: append(append([]cli.Flag{}, pauseFlags...), cli.StringFlag{
+		Name:  "reason",
+		Usage: "the reason that the votes pause",
+	}),
+	Usage: "pause the votes of an election or a poll",
+	Action: func(c *cli.Context) error {
+		err := deliverPause(c, ctrls.PAUSE)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The votes paused")
+		return nil
+	},
+}
+
+var ResumeCommand = cli.Command{
+	Name:    "resume",
+	Aliases: []string{"re"},
+	Flags:   pauseFlags,
+	Usage:   "resume the votes of a paused election or poll",
+	Action: func(c *cli.Context) error {
+		err := deliverPause(c, ctrls.RESUME)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The votes resumed")
+		return nil
+	},
+}
+
+func deliverPause(c *cli.Context, dt ctrls.DeliveryType) error {
+	filename := c.String("key")
+	if len(filename) == 0 {
+		return errors.New("Error: filename is missing")
+	}
+	priv, err := fileKey(filename)
+	if err != nil {
+		return errors.New("Error: " + err.Error())
+	}
+	pdd := ctrls.PauseDeliveryData{}
+	pubB, _ := priv.GetPublic().Bytes()
+	pdd.From = hex.EncodeToString(pubB)
+	pdd.ElectionID = c.String("election")
+	pdd.PollHash = c.String("hash")
+	if len(pdd.ElectionID) == 0 && len(pdd.PollHash) == 0 {
+		return errors.New("Error: election or hash is missing")
+	}
+	pdd.Reason = c.String("reason")
+	b, _ := json.Marshal(pdd)
+	sigB, err := priv.Sign(b)
+	if err != nil {
+		return errors.New("Error: " + err.Error())
+	}
+	tvd := ctrls.TVDelivery{}
+	tvd.Data = pdd
+	tvd.Type = dt
+	tvd.Signature = sigB
+	b, _ = json.Marshal(tvd)
+	_, err = deliver(b)
+	return err
+}
+
+var CancelPollCommand = cli.Command{
+	Name:    "cancel-poll",
+	Aliases: []string{"cnp"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the key",
+		},
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+		cli.StringFlag{
+			Name:  "reason",
+			Usage: "the reason that the poll is cancelled",
+		},
+		cli.StringFlag{
+			Name:  "replacement",
+			Usage: "the hash of the poll that replaces the cancelled poll",
+		},
+	},
+	Usage: "cancel a poll, and its results become void",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+		reason := c.String("reason")
+		if len(reason) == 0 {
+			return errors.New("Error: reason is missing")
+		}
+		cdd := ctrls.CancelPollDeliveryData{}
+		pubB, _ := priv.GetPublic().Bytes()
+		cdd.From = hex.EncodeToString(pubB)
+		cdd.PollHash = hash
+		cdd.Reason = reason
+		cdd.Replacement = c.String("replacement")
+		b, _ := json.Marshal(cdd)
+		sigB, err := priv.Sign(b)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		tvd := ctrls.TVDelivery{}
+		tvd.Data = cdd
+		tvd.Type = ctrls.CANCEL_POLL
+		tvd.Signature = sigB
+		b, _ = json.Marshal(tvd)
+		_, err = deliver(b)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The poll cancelled")
+		return nil
+	},
+}
+
+func printVoidPoll(item ctrls.ItemPollQuery) {
+	if item.Void {
+		fmt.Println("WARNING: the poll is cancelled and its results are void")
+		if len(item.Replacement) > 0 {
+			fmt.Println("Replacement:", item.Replacement)
+		}
+	}
+}
+
+var votersFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "key",
+		Usage: "the filename of the key",
+	},
+	cli.StringFlag{
+		Name:  "election",
+		Usage: "the election's ID",
+	},
+	cli.StringFlag{
+		Name:  "voters",
+		Usage: "the voters' public keys seperated by comma",
+	},
+}
+
+var groupsFlag = cli.StringSliceFlag{
+	Name:  "group",
+	Usage: "the groups of a voter, like publickey=group1,group2, and it can be repeated for each voter",
+}
+
+var AddVotersCommand = cli.Command{
+	Name:    "add-voters",
+	Aliases: []string{"adv"},
+	Flags:   append(append([]cli.Flag{}, votersFlags...), groupsFlag),
+	Usage:   "add voters to an existing election",
+	Action: func(c *cli.Context) error {
+		err := deliverVoters(c, ctrls.ADD_VOTERS)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The voters added")
+		return nil
+	},
+}
+
+var RemoveVotersCommand = cli.Command{
+	Name:    "remove-voters",
+	Aliases: []string{"rmv"},
+	Flags:   votersFlags,
+	Usage:   "remove voters from an existing election",
+	Action: func(c *cli.Context) error {
+		err := deliverVoters(c, ctrls.REMOVE_VOTERS)
+		if err != nil {
+			return err
+		}
+		fmt.Println("The voters removed")
+		return nil
+	},
+}
+
+func deliverVoters(c *cli.Context, dt ctrls.DeliveryType) error {
+	filename := c.String("key")
+	if len(filename) == 0 {
+		return errors.New("Error: filename is missing")
+	}
+	priv, err := fileKey(filename)
+	if err != nil {
+		return errors.New("Error: " + err.Error())
+	}
+	electionID := c.String("election")
+	if len(electionID) == 0 {
+		return errors.New("Error: election is missing")
+	}
+	strVoters := c.String("voters")
+	if len(strVoters) == 0 {
+		return errors.New("Error: voters are missing")
+	}
+	vdd := ctrls.VotersDeliveryData{}
+	pubB, _ := priv.GetPublic().Bytes()
+	vdd.From = hex.EncodeToString(pubB)
+	vdd.ElectionID = electionID
+	vdd.Voters = strings.Split(strVoters, ",")
+	vdd.Groups, err = parseGroups(c.StringSlice("group"))
+	if err != nil {
+		return err
+	}
+	b, _ := json.Marshal(vdd)
+	sigB, err := priv.Sign(b)
+	if err != nil {
+		return errors.New("Error: " + err.Error())
+	}
+	tvd := ctrls.TVDelivery{}
+	tvd.Data = vdd
+	tvd.Type = dt
+	tvd.Signature = sigB
+	b, _ = json.Marshal(tvd)
+	_, err = deliver(b)
+	return err
+}
+
+var QueryElectionsCommand = cli.Command{
+	Name:    "elections",
+	Aliases: []string{"e"},
+	Usage:   "list the election's IDs",
+	Action: func(c *cli.Context) error {
+		value, err := query("/elections", nil)
+		if err != nil {
+			return err
+		}
+		les := ctrls.ListElectionQuery{}
+		json.Unmarshal(value, &les)
+		for _, v := range les {
+			fmt.Println("Election ID:", v.ID)
+			fmt.Println("Latest:", v.Latest)
+			fmt.Println("Number of voters:", v.NumberOfVoters)
+			fmt.Println("Status:", v.Status)
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var QueryLatestElectionCommand = cli.Command{
+	Name:    "latest-election",
+	Aliases: []string{"le"},
+	Usage:   "get the latest election's IDs",
+	Action: func(c *cli.Context) error {
+		value, err := query("/elections/latest", nil)
+		if err != nil {
+			return err
+		}
+
+		v := ctrls.ItemElectionQuery{}
+		json.Unmarshal(value, &v)
+		fmt.Println("Election ID:", v.ID)
+		fmt.Println("Latest:", v.Latest)
+		fmt.Println("Number of voters:", v.NumberOfVoters)
+		fmt.Println("Status:", v.Status)
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var QueryElectionCommand = cli.Command{
+	Name:    "election",
+	Aliases: []string{"el"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "id",
+			Usage: "the election's ID",
+		},
+	},
+	Usage: "get the election's details and its polls",
+	Action: func(c *cli.Context) error {
+		id := c.String("id")
+		if len(id) == 0 {
+			return errors.New("Error: id is missing")
+		}
+		value, err := query("/elections/"+id, nil)
+		if err != nil {
+			return err
+		}
+
+		v := ctrls.ElectionDetailsQuery{}
+		json.Unmarshal(value, &v)
+		fmt.Println("Election ID:", v.ID)
+		fmt.Println("Name:", v.Name)
+		fmt.Println("Description:", v.Description)
+		fmt.Println("Jurisdiction:", v.Jurisdiction)
+		fmt.Println("Status:", v.Status)
+		if v.Paused != nil {
+			fmt.Println("Paused at height", v.Paused.Height, "because:", v.Paused.Reason)
+		}
+		fmt.Println("Number of voters:", v.NumberOfVoters)
+		if v.InitiativeShare > 0 {
+			fmt.Println("Initiative's share:", v.InitiativeShare, "%")
+		}
+		if v.Invitations > 0 {
+			fmt.Println("Invitations:", v.PendingInvitations, "pending of", v.Invitations)
+		}
+		fmt.Println("Created at height:", v.CreatedHeight)
+		fmt.Println("Created at time:", time.Unix(v.CreatedTime, 0).UTC())
+		fmt.Println("Creator:", v.Creator)
+		for _, p := range v.Polls {
+			fmt.Println()
+			fmt.Println("Poll hash:", p.PollHash)
+			fmt.Println("Latest:", p.Latest)
+			printVoidPoll(p)
+		}
+		return nil
+	},
+}
+
+var QueryVoterStatusCommand = cli.Command{
+	Name:    "status",
+	Aliases: []string{"st"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "key",
+			Usage: "the filename of the voter's key",
+		},
+	},
+	Usage: "get the voter's elections, and the open polls that the voter can vote, with all the elections that have the voters' root",
+	Action: func(c *cli.Context) error {
+		filename := c.String("key")
+		if len(filename) == 0 {
+			return errors.New("Error: filename is missing")
+		}
+		priv, err := fileKey(filename)
+		if err != nil {
+			return errors.New("Error: " + err.Error())
+		}
+		pubB, _ := priv.GetPublic().Bytes()
+		value, err := query("/voters/"+hex.EncodeToString(pubB), nil)
+		if err != nil {
+			return err
+		}
+
+		v := ctrls.VoterStatusQuery{}
+		json.Unmarshal(value, &v)
+		fmt.Println("Voter:", v.Voter)
+		if len(v.Elections) == 0 {
+			fmt.Println("The voter is not in the list of voters of any election.")
+		}
+		for _, e := range v.Elections {
+			fmt.Println()
+			fmt.Println("Election ID:", e.ID)
+			fmt.Println("Name:", e.Name)
+			fmt.Println("Status:", e.Status)
+			if e.MembershipByProof {
+				fmt.Println("The election has the voters' root, so the voter is in it only with a Merkle proof of the voters' file.")
+			}
+			if len(e.Groups) > 0 {
+				fmt.Println("Groups:", strings.Join(e.Groups, ", "))
+			}
+			if len(e.Polls) == 0 {
+				fmt.Println("There is not any open poll for the voter.")
+			}
+			for _, p := range e.Polls {
+				fmt.Println("Poll hash:", p.PollHash)
+				if p.EndHeight > 0 {
+					fmt.Println("The poll ends at height", p.EndHeight)
+				}
+				if p.Paused != nil {
+					fmt.Println("Paused at height", p.Paused.Height, "because:", p.Paused.Reason)
+				}
+				if p.Anonymous {
+					fmt.Println("Voted: the votes are not linked to the voter's key")
+				} else {
+					fmt.Println("Voted:", p.Voted)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+var QueryAmendmentsCommand = cli.Command{
+	Name:    "amendments",
+	Aliases: []string{"am"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+	},
+	Usage: "get the election's voters and the amendments on them",
+	Action: func(c *cli.Context) error {
+		electionID := c.String("election")
+		if len(electionID) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		b, _ := json.Marshal(ctrls.ElectionQuery{ID: electionID})
+		value, err := query("/elections/amendments", b)
+		if err != nil {
+			return err
+		}
+
+		v := ctrls.ElectionAmendmentsQuery{}
+		json.Unmarshal(value, &v)
+		fmt.Println("Election ID:", v.ID)
+		fmt.Println("Number of voters:", len(v.Voters))
+		for _, a := range v.Amendments {
+			fmt.Println()
+			fmt.Println("Height:", a.Height)
+			for _, voter := range a.Added {
+				fmt.Println("Added:", voter)
+			}
+			for _, voter := range a.Removed {
+				fmt.Println("Removed:", voter)
+			}
+		}
+		return nil
+	},
+}
+
+var QueryPollsCommand = cli.Command{
+	Name:    "polls",
+	Aliases: []string{"p"},
+	Usage:   "list the poll's hash keys",
+	Action: func(c *cli.Context) error {
+
+		value, err := query("/polls", nil)
+		if err != nil {
+			return err
+		}
+
+		pes := ctrls.ListPollQuery{}
+		json.Unmarshal(value, &pes)
+		for _, v := range pes {
+			fmt.Println("Poll's Hash:", v.PollHash)
+			fmt.Println("Latest:", v.Latest)
+			printVoidPoll(v)
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
+
+var QueryLatestPollCommand = cli.Command{
+	Name:    "latest-poll",
+	Aliases: []string{"lp"},
+	Usage:   "get the latest poll's hash key",
+	Action: func(c *cli.Context) error {
+		value, err := query("/polls/latest", nil)
+		if err != nil {
+			return err
+		}
+
+		v := ctrls.ItemPollQuery{}
+		json.Unmarshal(value, &v)
+		fmt.Println("Poll's Hash:", v.PollHash)
+		fmt.Println("Latest:", v.Latest)
+		printVoidPoll(v)
+		fmt.Println()
+
+		return nil
+	},
+}
+
+var QueryScheduledPollsCommand = cli.Command{
+	Name:    "scheduled-polls",
+	Aliases: []string{"sp"},
+	Usage:   "list the scheduled polls and their activation",
+	Action: func(c *cli.Context) error {
+		value, err := query("/polls/scheduled", nil)
+		if err != nil {
+			return err
+		}
+
+		list := []ctrls.ScheduledPollState{}
+		json.Unmarshal(value, &list)
+		for _, v := range list {
+			fmt.Println("Poll's Hash:", v.Poll.PollHash)
+			fmt.Println("Election ID:", v.Poll.ElectionID)
+			if v.Poll.ActivationHeight > 0 {
+				fmt.Println("Activation at height:", v.Poll.ActivationHeight)
+			} else {
+				fmt.Println("Activation at time:", time.Unix(v.Poll.ActivationTime, 0).UTC())
+			}
+			fmt.Println("Status:", v.Status)
+			if v.ActivatedHeight > 0 {
+				fmt.Println("Activated at height:", v.ActivatedHeight)
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var QueryResultsCommand = cli.Command{
+	Name:    "results",
+	Aliases: []string{"r"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "hash",
+			Usage: "the poll's directory as an IPFS hash",
+		},
+	},
+	Usage: "get the latest results based on poll's hash key",
+	Action: func(c *cli.Context) error {
+		hash := c.String("hash")
+		if len(hash) == 0 {
+			return errors.New("Error: hash is missing")
+		}
+
+		b, _ := json.Marshal(ctrls.PollQuery{PollHash: hash})
 		value, err := query("/votes", b)
 		if err != nil {
 			return err
@@ -329,12 +2501,256 @@ var QueryResultsCommand = cli.Command{
 
 		v := ctrls.PollVotesQuery{}
 		json.Unmarshal(value, &v)
-		for k, n := range v.Choices {
-			fmt.Println("Votes for choice '"+k+"':", n)
+		if v.Void {
+			fmt.Println("WARNING: the poll is cancelled at height", v.Cancelled.Height, "because:", v.Cancelled.Reason)
+			fmt.Println("WARNING: the results are void and they are kept only for the audit")
+			if len(v.Cancelled.Replacement) > 0 {
+				fmt.Println("WARNING: the replacement poll is", v.Cancelled.Replacement)
+			}
+		}
+		if v.Paused != nil {
+			fmt.Println("The poll is paused at height", v.Paused.Height, "because:", v.Paused.Reason)
+		}
+		if v.EndHeight > 0 {
+			fmt.Println("The poll ends at height", v.EndHeight)
+		}
+		for _, o := range v.EmbargoOverrides {
+			fmt.Println("The embargo is overridden at height", o.Height, "by", o.From, "because:", o.Reason)
+		}
+		if v.IssuedCredentials > 0 {
+			fmt.Println("Credential votes:", v.CredentialVotes, "of", v.IssuedCredentials, "issued credentials")
+		}
+		if v.Embargoed {
+			fmt.Println("The results are under embargo until the poll closes.")
+			fmt.Println("Number of voters:", v.NumberOfVotes)
+			return nil
+		}
+		if len(v.RunoffOf) > 0 {
+			fmt.Println("The poll is the runoff of", v.RunoffOf)
+		}
+		if len(v.Runoff) > 0 {
+			fmt.Println("No choice has enough votes, and the runoff poll is", v.Runoff)
+		}
+		if v.Encrypted && !v.Tallied {
+			fmt.Println("The poll is encrypted and the trustees have not decrypted the results yet.")
+		} else if len(v.Questions) > 0 {
+			for q, choices := range v.Questions {
+				for k, n := range choices {
+					fmt.Println("Votes for question '"+q+"' and choice '"+k+"':", n)
+				}
+			}
+		} else if v.Method == ctrls.METHOD_SCHULZE {
+			for a, row := range v.Pairwise {
+				for b, n := range row {
+					fmt.Println("Voters that prefer '"+a+"' from '"+b+"':", n)
+				}
+			}
+			fmt.Println("Ranking:", strings.Join(v.Ranking, ", "))
+		} else if v.Method == ctrls.METHOD_BUDGETING {
+			for k, n := range v.Choices {
+				fmt.Println("Approvals for project '"+k+"':", n)
+			}
+			fmt.Println("Funded projects:", strings.Join(v.Funded, ", "))
+		} else if v.Method == ctrls.METHOD_SCORE {
+			for k, n := range v.AverageScores {
+				fmt.Println("Average score for choice '"+k+"':", n)
+			}
+		} else {
+			for k, n := range v.Choices {
+				fmt.Println("Votes for choice '"+k+"':", n)
+			}
+			for w, n := range v.WriteIns {
+				fmt.Println("Votes for write-in '"+w+"':", n)
+			}
 		}
 		fmt.Println("Number of voters:", v.NumberOfVotes)
+		for g, n := range v.GroupVotes {
+			fmt.Println("Number of voters in group '"+g+"':", n)
+			for k, cn := range v.GroupChoices[g] {
+				fmt.Println("  Votes for choice '"+k+"':", cn)
+			}
+		}
 		fmt.Println()
 
 		return nil
 	},
 }
+
+var QueryInitiativesCommand = cli.Command{
+	Name:    "initiatives",
+	Aliases: []string{"in"},
+	Usage:   "list the voters' initiatives and their support",
+	Action: func(c *cli.Context) error {
+		value, err := query("/initiatives", nil)
+		if err != nil {
+			return err
+		}
+
+		list := ctrls.ListInitiativeQuery{}
+		json.Unmarshal(value, &list)
+		for _, v := range list {
+			fmt.Println("Poll's Hash:", v.PollHash)
+			fmt.Println("Election ID:", v.ElectionID)
+			fmt.Println("Proposer:", v.Proposer)
+			fmt.Println("Support:", v.Supporters, "of", v.Needed)
+			fmt.Println("Poll created:", v.Created)
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var QueryRegistrationsCommand = cli.Command{
+	Name:    "registrations",
+	Aliases: []string{"regs"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "election",
+			Usage: "the election's ID",
+		},
+	},
+	Usage: "list the pending registrations of the election",
+	Action: func(c *cli.Context) error {
+		election := c.String("election")
+		if len(election) == 0 {
+			return errors.New("Error: election is missing")
+		}
+		eq := ctrls.ElectionQuery{ID: election}
+		b, _ := json.Marshal(eq)
+		value, err := query("/registrations/pending", b)
+		if err != nil {
+			return err
+		}
+
+		list := ctrls.ListRegistrationQuery{}
+		json.Unmarshal(value, &list)
+		for _, v := range list {
+			fmt.Println("Voter:", v.Voter)
+			fmt.Println("Requested at height:", v.Height)
+			names := []string{}
+			for k := range v.Attributes {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			for _, k := range names {
+				fmt.Println(k+":", v.Attributes[k])
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+var VerifyReceiptCommand = cli.Command{
+	Name:    "verify-receipt",
+	Aliases: []string{"vr"},
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "receipt",
+			Usage: "the filename of the vote's receipt",
+		},
+	},
+	Usage: "verify from the node that the vote of the receipt is committed and counted for the poll",
+	Action: func(c *cli.Context) error {
+		filename := c.String("receipt")
+		if len(filename) == 0 {
+			return errors.New("Error: receipt is missing")
+		}
+		vr, err := fileReceipt(filename)
+		if err != nil {
+			return err
+		}
+		hash, err := hex.DecodeString(vr.Hash)
+		if err != nil {
+			return errors.New("Error: the receipt's hash is not a correct hex")
+		}
+		rt, err := committedTx(hash)
+		if err != nil {
+			return err
+		}
+		if rt.Height != vr.Height {
+			return errors.New("Error: the transaction is committed at height " + strconv.FormatInt(rt.Height, 10) + " and not at the receipt's height")
+		}
+		if !bytes.Equal(rt.Tx, vr.Tx) {
+			return errors.New("Error: the committed transaction is not the receipt's vote")
+		}
+		if rt.TxResult.Code != CodeTypeOK {
+			return errors.New("Error: the vote was not counted: " + rt.TxResult.Log)
+		}
+
+		tvd := ctrls.TVDelivery{}
+		err = json.Unmarshal(vr.Tx, &tvd)
+		if err != nil {
+			return errors.New("Error: json problem with the receipt's vote " + err.Error())
+		}
+		// the anonymous vote's ring signature was verified by the node
+		if tvd.Type != ctrls.ANONYMOUS_VOTE {
+			ver, err := tvd.VerifySignature()
+			if err != nil || !ver {
+				return errors.New("Error: the signature does not verify the receipt's vote")
+			}
+		}
+		vd := ctrls.VoteDeliveryData{}
+		switch tvd.Type {
+		case ctrls.VOTE:
+			vd = tvd.GetVoteDeliveryData()
+		case ctrls.ANONYMOUS_VOTE:
+			avd := tvd.GetAnonymousVoteDeliveryData()
+			vd = avd.GetVoteDeliveryData()
+		case ctrls.CREDENTIAL_VOTE:
+			cvd := tvd.GetCredentialVoteDeliveryData()
+			vd = cvd.GetVoteDeliveryData()
+		case ctrls.ENCRYPTED_VOTE:
+			evd := tvd.GetEncryptedVoteDeliveryData()
+			vd.From = evd.From
+			vd.PollHash = evd.PollHash
+		default:
+			return errors.New("Error: the receipt is not for a vote")
+		}
+
+		// the poll has the key that voted, and it is the key image for the anonymous vote
+		// or the voting key for the vote with a credential
+		b, _ := json.Marshal(ctrls.PollVotedQuery{PollHash: vd.PollHash, From: vd.From})
+		value, err := query("/polls/voted", b)
+		if err != nil {
+			return err
+		}
+		voted := ctrls.PollVotedQuery{}
+		json.Unmarshal(value, &voted)
+		if !voted.Voted {
+			return errors.New("Error: the poll has not the vote of " + vd.From)
+		}
+
+		b, _ = json.Marshal(ctrls.PollQuery{PollHash: vd.PollHash})
+		value, err = query("/votes", b)
+		if err != nil {
+			return err
+		}
+		pvq := ctrls.PollVotesQuery{}
+		json.Unmarshal(value, &pvq)
+		if pvq.Void {
+			fmt.Println("WARNING: the poll is cancelled at height", pvq.Cancelled.Height, "because:", pvq.Cancelled.Reason)
+			fmt.Println("WARNING: the vote is kept only for the audit, because the results are void")
+		}
+		if pvq.Paused != nil {
+			fmt.Println("The poll is paused at height", pvq.Paused.Height, "because:", pvq.Paused.Reason)
+		}
+		if pvq.Embargoed {
+			fmt.Println("The results are under embargo, so the vote's choice can be verified after the poll closes")
+		} else if tvd.Type != ctrls.ENCRYPTED_VOTE {
+			err = verifyCountedBallot(vd, pvq)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Println("The vote is committed at height", rt.Height, "for the poll", vd.PollHash)
+		if tvd.Type == ctrls.ENCRYPTED_VOTE {
+			fmt.Println("The ballot is encrypted")
+		} else {
+			ballot, _ := json.Marshal(vd)
+			fmt.Println("The ballot:", string(ballot))
+		}
+		return nil
+	},
+}
@@ -11,14 +11,48 @@ func main() {
 	app := cli.NewApp()
 	app.Commands = []cli.Command{
 		GenerateKeyCommand,
+		InvitationsCommand,
 		CreateElectionCommand,
+		ElectionStatusCommand,
+		PauseCommand,
+		ResumeCommand,
 		AddPollCommand,
+		CancelPollCommand,
+		AddVotersCommand,
+		RemoveVotersCommand,
+		RegisterCommand,
+		ApproveRegistrationCommand,
+		RejectRegistrationCommand,
+		ReplaceKeyCommand,
+		RedeemInvitationCommand,
 		VoteCommand,
+		TrusteeKeysCommand,
+		DealKeyCommand,
+		TrusteeShareCommand,
+		EncryptedVoteCommand,
+		AnonymousVoteCommand,
+		CredentialKeyCommand,
+		RequestCredentialCommand,
+		IssueCredentialCommand,
+		UnblindCredentialCommand,
+		VoteAnonymouslyCommand,
+		DecryptCommand,
+		MergeWriteInsCommand,
+		OverrideEmbargoCommand,
+		ProposeInitiativeCommand,
+		SupportInitiativeCommand,
 		QueryElectionsCommand,
 		QueryLatestElectionCommand,
+		QueryElectionCommand,
+		QueryVoterStatusCommand,
+		QueryAmendmentsCommand,
 		QueryPollsCommand,
 		QueryLatestPollCommand,
+		QueryScheduledPollsCommand,
 		QueryResultsCommand,
+		QueryInitiativesCommand,
+		QueryRegistrationsCommand,
+		VerifyReceiptCommand,
 	}
 	err := app.Run(os.Args)
 	if err != nil {
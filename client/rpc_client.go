@@ -4,19 +4,30 @@ import (
 	"errors"
 
 	client "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	"github.com/tendermint/tendermint/types"
 )
 
-func deliver(b []byte) (uint32, error) {
+// DeliveryResult has the transaction's hash and the block's height, after the delivery is committed
+type DeliveryResult struct {
+	Code   uint32
+	Hash   []byte
+	Height int64
+}
+
+func deliver(b []byte) (*DeliveryResult, error) {
 	cli := client.NewHTTP(Conf.NodeDaemon, "/websocket")
 	btc, err := cli.BroadcastTxCommit(types.Tx(b))
 	if err != nil {
-		return CodeTypeClientError, errors.New("Error: " + err.Error())
+		return &DeliveryResult{Code: CodeTypeClientError}, errors.New("Error: " + err.Error())
 	}
 	if btc.CheckTx.Code > CodeTypeOK {
-		return btc.CheckTx.Code, errors.New("Error: " + btc.CheckTx.Log)
+		return &DeliveryResult{Code: btc.CheckTx.Code}, errors.New("Error: " + btc.CheckTx.Log)
 	}
-	return CodeTypeOK, nil
+	if btc.DeliverTx.Code > CodeTypeOK {
+		return &DeliveryResult{Code: btc.DeliverTx.Code}, errors.New("Error: " + btc.DeliverTx.Log)
+	}
+	return &DeliveryResult{Code: CodeTypeOK, Hash: btc.Hash, Height: btc.Height}, nil
 }
 
 func query(path string, data []byte) ([]byte, error) {
@@ -30,3 +41,13 @@ func query(path string, data []byte) ([]byte, error) {
 	}
 	return q.Response.Value, nil
 }
+
+// committedTx returns the transaction from the node, when it is committed in a block
+func committedTx(hash []byte) (*ctypes.ResultTx, error) {
+	cli := client.NewHTTP(Conf.NodeDaemon, "/websocket")
+	rt, err := cli.Tx(hash, false)
+	if err != nil {
+		return nil, errors.New("Error: " + err.Error())
+	}
+	return rt, nil
+}
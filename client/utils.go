@@ -1,11 +1,17 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"strconv"
+	"strings"
 
 	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/mragiadakos/tendervoting/server/ctrls"
 )
 
 const (
@@ -14,6 +20,7 @@ const (
 	CodeTypeBadNonce      uint32 = 2
 	CodeTypeUnauthorized  uint32 = 3
 	CodeTypeClientError   uint32 = 4
+	CodeTypePaused        uint32 = 5
 )
 
 type KeyJson struct {
@@ -21,6 +28,103 @@ type KeyJson struct {
 	PrivateKey []byte
 }
 
+// CredentialJson is the voter's secret for the credential, and it should not be shared
+type CredentialJson struct {
+	ElectionID     string
+	Voter          string
+	VotingKey      KeyJson
+	Blinded        string
+	BlindingFactor string
+	Credential     string
+}
+
+func fileCredential(filename string) (*CredentialJson, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.New("Error: " + err.Error())
+	}
+	cj := CredentialJson{}
+	err = json.Unmarshal(b, &cj)
+	if err != nil {
+		return nil, errors.New("Error: json problem with the credential " + err.Error())
+	}
+	return &cj, nil
+}
+
+func fileCredentialKey(filename string) (*ctrls.CredentialPrivateKey, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.New("Error: " + err.Error())
+	}
+	cpk := ctrls.CredentialPrivateKey{}
+	err = json.Unmarshal(b, &cpk)
+	if err != nil {
+		return nil, errors.New("Error: json problem with the credential key " + err.Error())
+	}
+	return &cpk, nil
+}
+
+// fileVoters reads the voters' public keys from the file, one for each line
+func fileVoters(filename string) ([]string, error) {
+	return fileLines(filename)
+}
+
+// fileLines reads the lines of the file that are not empty
+func fileLines(filename string) ([]string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.New("Error: " + err.Error())
+	}
+	lines := []string{}
+	for _, v := range strings.Split(string(b), "\n") {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 {
+			lines = append(lines, v)
+		}
+	}
+	return lines, nil
+}
+
+// the invitation's code is easy to type from the paper, without the letters and the numbers that look the same
+const invitationAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// invitationCode returns a random code like XXXX-XXXX-XXXX-XXXX
+func invitationCode() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	code := []byte{}
+	for i, v := range b {
+		if i > 0 && i%4 == 0 {
+			code = append(code, '-')
+		}
+		code = append(code, invitationAlphabet[int(v)%len(invitationAlphabet)])
+	}
+	return string(code), nil
+}
+
+// fileVoterProof builds the Merkle tree from the voters' file and returns the proof for the voter
+func fileVoterProof(filename, voter string) (ctrls.MerkleProof, error) {
+	if len(filename) == 0 {
+		return nil, nil
+	}
+	voters, err := fileVoters(filename)
+	if err != nil {
+		return nil, err
+	}
+	mt, err := ctrls.NewMerkleTree(voters)
+	if err != nil {
+		return nil, errors.New("Error: " + err.Error())
+	}
+	proof, err := mt.Proof(voter)
+	if err != nil {
+		return nil, errors.New("Error: " + err.Error())
+	}
+	return proof, nil
+}
+
 func fileKey(filename string) (crypto.PrivKey, error) {
 	b, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -39,3 +143,174 @@ func fileKey(filename string) (crypto.PrivKey, error) {
 	}
 	return edKey, nil
 }
+
+// parseAnswers reads the answers like question=choice1,choice2
+func parseAnswers(answers []string) (map[string][]string, error) {
+	if len(answers) == 0 {
+		return nil, nil
+	}
+	out := map[string][]string{}
+	for _, v := range answers {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, errors.New("Error: the answer " + v + " should be like question=choice1,choice2")
+		}
+		out[parts[0]] = []string{}
+		if len(parts[1]) > 0 {
+			out[parts[0]] = strings.Split(parts[1], ",")
+		}
+	}
+	return out, nil
+}
+
+// parseList parses the choices like choice1,choice2, for the ranking or the projects
+func parseList(list string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// parseChoiceNumbers parses the values like choice=number, for the scores or the quadratic votes
+func parseChoiceNumbers(name string, values []string) (map[string]int, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := map[string]int{}
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, errors.New("Error: the " + name + " " + v + " should be like choice=number")
+		}
+		n, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, errors.New("Error: the " + name + " " + v + " is not a number")
+		}
+		out[parts[0]] = n
+	}
+	return out, nil
+}
+
+func parseGroups(groups []string) (map[string][]string, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	out := map[string][]string{}
+	for _, v := range groups {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, errors.New("Error: the group " + v + " should be like publickey=group1,group2")
+		}
+		out[parts[0]] = strings.Split(parts[1], ",")
+	}
+	return out, nil
+}
+
+// parseAttributes parses the registration's attributes like name=value
+func parseAttributes(attributes []string) (map[string]string, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	out := map[string]string{}
+	for _, v := range attributes {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, errors.New("Error: the attribute " + v + " should be like name=value")
+		}
+		out[parts[0]] = parts[1]
+	}
+	return out, nil
+}
+
+// VoteReceipt is the proof of the voter that the signed vote was committed in the block
+type VoteReceipt struct {
+	Hash   string
+	Height int64
+	Tx     []byte
+}
+
+func saveReceipt(filename string, dr *DeliveryResult, tx []byte) error {
+	vr := VoteReceipt{Hash: hex.EncodeToString(dr.Hash), Height: dr.Height, Tx: tx}
+	if len(filename) == 0 {
+		filename = "receipt-" + vr.Hash + ".json"
+	}
+	b, _ := json.MarshalIndent(vr, "", " ")
+	err := ioutil.WriteFile(filename, b, 0644)
+	if err != nil {
+		return errors.New("Error: " + err.Error())
+	}
+	fmt.Println("The receipt saved in", filename)
+	return nil
+}
+
+func fileReceipt(filename string) (*VoteReceipt, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.New("Error: " + err.Error())
+	}
+	vr := VoteReceipt{}
+	err = json.Unmarshal(b, &vr)
+	if err != nil {
+		return nil, errors.New("Error: json problem with the receipt " + err.Error())
+	}
+	return &vr, nil
+}
+
+// verifyCountedBallot checks that the results have the ballot's votes, for each kind of ballot
+func verifyCountedBallot(vd ctrls.VoteDeliveryData, pvq ctrls.PollVotesQuery) error {
+	switch {
+	case len(vd.WriteIn) > 0:
+		// the write-in could be merged to a candidate after the vote
+		candidate := vd.WriteIn
+		for _, m := range pvq.WriteInMerges {
+			for _, v := range m.Variants {
+				if v == candidate {
+					candidate = m.Candidate
+				}
+			}
+		}
+		if pvq.WriteIns[candidate] == 0 {
+			return errors.New("Error: the poll has not votes for the write-in " + candidate)
+		}
+	case len(vd.Answers) > 0:
+		for q, choices := range vd.Answers {
+			for _, c := range choices {
+				if pvq.Questions[q][c] == 0 {
+					return errors.New("Error: the poll has not votes for the choice " + c + " of the question " + q)
+				}
+			}
+		}
+	case len(vd.Ranking) > 0:
+		// each choice of the ranking is preferred to the next ones in the pairwise results
+		for i := 0; i < len(vd.Ranking); i++ {
+			for _, v := range vd.Ranking[i+1:] {
+				if pvq.Pairwise[vd.Ranking[i]][v] == 0 {
+					return errors.New("Error: the poll has not votes that prefer " + vd.Ranking[i] + " to " + v)
+				}
+			}
+		}
+	case len(vd.Scores) > 0:
+		for c, n := range vd.Scores {
+			if pvq.Choices[c] < n {
+				return errors.New("Error: the poll has less than " + strconv.Itoa(n) + " points for the choice " + c)
+			}
+		}
+	case len(vd.QuadraticVotes) > 0:
+		for c, n := range vd.QuadraticVotes {
+			if pvq.Choices[c] < n {
+				return errors.New("Error: the poll has less than " + strconv.Itoa(n) + " votes for the choice " + c)
+			}
+		}
+	case len(vd.Projects) > 0:
+		for _, p := range vd.Projects {
+			if pvq.Choices[p] == 0 {
+				return errors.New("Error: the poll has not votes for the project " + p)
+			}
+		}
+	case len(vd.Choice) > 0:
+		if pvq.Choices[vd.Choice] == 0 {
+			return errors.New("Error: the poll has not votes for the choice " + vd.Choice)
+		}
+	}
+	return nil
+}
@@ -17,3 +17,16 @@ func NewTVApplication() *TVApplication {
 	state := loadState(dbm.NewMemDB())
 	return &TVApplication{state: state}
 }
+
+func (app *TVApplication) BeginBlock(req types.RequestBeginBlock) types.ResponseBeginBlock {
+	app.state.Height = req.Header.Height
+	app.state.Time = req.Header.Time
+	app.state.ActivatePolls()
+	return types.ResponseBeginBlock{}
+}
+
+// EndBlock creates the runoffs of the polls that end at the block
+func (app *TVApplication) EndBlock(req types.RequestEndBlock) types.ResponseEndBlock {
+	app.state.EndPolls(req.Height)
+	return types.ResponseEndBlock{}
+}
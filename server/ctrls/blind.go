@@ -0,0 +1,217 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// The credentials are RSA blind signatures from the gonverment on the voters' voting keys.
+// The voter blinds the message with a random factor, so the gonverment signs it without seeing the voting key,
+// and after the unblinding nobody can link the voting key with the voter that requested the credential.
+
+const credentialKeyMinBits = 2048
+
+type CredentialKey struct {
+	N string
+	E string
+}
+
+type CredentialPrivateKey struct {
+	CredentialKey
+	D string
+}
+
+func NewCredentialKey(bits int) (*CredentialPrivateKey, error) {
+	if bits < credentialKeyMinBits {
+		return nil, errors.New("The credential key should have at least 2048 bits.")
+	}
+	rk, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+	cpk := new(CredentialPrivateKey)
+	cpk.N = bigToHex(rk.N)
+	cpk.E = bigToHex(big.NewInt(int64(rk.E)))
+	cpk.D = bigToHex(rk.D)
+	return cpk, nil
+}
+
+func (ck *CredentialKey) numbers() (*big.Int, *big.Int, error) {
+	n, err := hexToBig(ck.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := hexToBig(ck.E)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n, e, nil
+}
+
+func (ck *CredentialKey) Validate() error {
+	n, e, err := ck.numbers()
+	if err != nil {
+		return err
+	}
+	if n.BitLen() < credentialKeyMinBits {
+		return errors.New("The credential key should have at least 2048 bits.")
+	}
+	if n.Bit(0) == 0 {
+		return errors.New("The credential key's modulus is not odd.")
+	}
+	if e.Cmp(big.NewInt(3)) < 0 || e.Bit(0) == 0 {
+		return errors.New("The credential key's exponent should be odd and at least 3.")
+	}
+	return nil
+}
+
+// CredentialMessage is the message that the gonverment signs blindly for the voting key in the election
+func CredentialMessage(electionID, votingKeyHex string) []byte {
+	return []byte("credential:" + electionID + "-" + votingKeyHex)
+}
+
+// hashToModulus is a full domain hash of the message in Z_N
+func (ck *CredentialKey) hashToModulus(msg []byte) (*big.Int, error) {
+	n, _, err := ck.numbers()
+	if err != nil {
+		return nil, err
+	}
+	out := []byte{}
+	counter := make([]byte, 4)
+	for i := uint32(0); len(out) < len(n.Bytes())+16; i++ {
+		binary.BigEndian.PutUint32(counter, i)
+		h := sha256.Sum256(append(append([]byte{}, counter...), msg...))
+		out = append(out, h[:]...)
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(out), n), nil
+}
+
+// Blind returns the blinded message and the blinding factor, both as hex
+func (ck *CredentialKey) Blind(msg []byte) (string, string, error) {
+	n, e, err := ck.numbers()
+	if err != nil {
+		return "", "", err
+	}
+	m, err := ck.hashToModulus(msg)
+	if err != nil {
+		return "", "", err
+	}
+	var r *big.Int
+	for {
+		r, err = rand.Int(rand.Reader, n)
+		if err != nil {
+			return "", "", err
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, n).Cmp(big.NewInt(1)) == 0 {
+			break
+		}
+	}
+	blinded := new(big.Int).Exp(r, e, n)
+	blinded.Mul(blinded, m)
+	blinded.Mod(blinded, n)
+	return bigToHex(blinded), bigToHex(r), nil
+}
+
+func (cpk *CredentialPrivateKey) SignBlinded(blindedHex string) (string, error) {
+	n, _, err := cpk.numbers()
+	if err != nil {
+		return "", err
+	}
+	d, err := hexToBig(cpk.D)
+	if err != nil {
+		return "", err
+	}
+	blinded, err := cpk.element(blindedHex)
+	if err != nil {
+		return "", err
+	}
+	return bigToHex(new(big.Int).Exp(blinded, d, n)), nil
+}
+
+// Unblind removes the blinding factor from the gonverment's signature
+func (ck *CredentialKey) Unblind(blindSignatureHex, rHex string) (string, error) {
+	n, _, err := ck.numbers()
+	if err != nil {
+		return "", err
+	}
+	s, err := ck.element(blindSignatureHex)
+	if err != nil {
+		return "", err
+	}
+	r, err := ck.element(rHex)
+	if err != nil {
+		return "", err
+	}
+	rInv := new(big.Int).ModInverse(r, n)
+	if rInv == nil {
+		return "", errors.New("The blinding factor is not invertible.")
+	}
+	s.Mul(s, rInv)
+	s.Mod(s, n)
+	return bigToHex(s), nil
+}
+
+// element returns the number from the hex, if it is between 1 and N-1
+func (ck *CredentialKey) element(h string) (*big.Int, error) {
+	n, _, err := ck.numbers()
+	if err != nil {
+		return nil, err
+	}
+	x, err := hexToBig(h)
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() <= 0 || x.Cmp(n) >= 0 {
+		return nil, errors.New("The number " + h + " is not in the range of the credential key.")
+	}
+	return x, nil
+}
+
+func (ck *CredentialKey) ValidateBlinded(blindedHex string) error {
+	_, err := ck.element(blindedHex)
+	return err
+}
+
+// VerifyBlindSignature checks the gonverment's signature on the blinded message
+func (ck *CredentialKey) VerifyBlindSignature(blindedHex, blindSignatureHex string) error {
+	n, e, err := ck.numbers()
+	if err != nil {
+		return err
+	}
+	blinded, err := ck.element(blindedHex)
+	if err != nil {
+		return err
+	}
+	s, err := ck.element(blindSignatureHex)
+	if err != nil {
+		return err
+	}
+	if new(big.Int).Exp(s, e, n).Cmp(blinded) != 0 {
+		return errors.New("The blind signature does not verify the blinded message.")
+	}
+	return nil
+}
+
+// VerifyCredential checks the unblinded signature on the message
+func (ck *CredentialKey) VerifyCredential(msg []byte, credentialHex string) error {
+	n, e, err := ck.numbers()
+	if err != nil {
+		return err
+	}
+	m, err := ck.hashToModulus(msg)
+	if err != nil {
+		return err
+	}
+	s, err := ck.element(credentialHex)
+	if err != nil {
+		return err
+	}
+	if new(big.Int).Exp(s, e, n).Cmp(m) != 0 {
+		return errors.New("The credential is not signed by the gonverment.")
+	}
+	return nil
+}
@@ -7,13 +7,59 @@ import (
 	"github.com/tendermint/abci/types"
 )
 
-func (app *TVApplication) verifyDelivery(tvd TVDelivery) (uint32, error) {
-	ver, err := tvd.VerifySignature()
+// validateNewPoll checks the poll.json of a new poll for the election, from the gonverment or from an initiative
+func (app *TVApplication) validateNewPoll(d PollDeliveryData, es *ElectionState) (uint32, error) {
+	if len(d.PollHash) == 0 {
+		return CodeTypeUnauthorized, errors.New("Missing the IPFS hash for the poll.")
+	}
+	pj, err := d.GetPollJsonFromPollHash()
 	if err != nil {
-		return CodeTypeEncodingError, err
+		return CodeTypeUnauthorized, err
+	}
+	for _, g := range pj.Groups {
+		if !es.HasGroup(g) {
+			return CodeTypeUnauthorized, errors.New("The group " + g + " does not exist in the election.")
+		}
+	}
+	if es.Encryption != nil && (len(pj.Questions) > 0 || pj.WriteIns || pj.GetMethod() != METHOD_PLURALITY) {
+		return CodeTypeUnauthorized, errors.New("The encrypted poll can not have questions, write-ins or another method than the plurality.")
+	}
+	// the encrypted votes are counted only after the decryption, so they can not decide a runoff when the poll ends
+	if es.Encryption != nil && pj.RunoffThreshold > 0 {
+		return CodeTypeUnauthorized, errors.New("The encrypted poll can not have a runoff.")
+	}
+	if es.Encryption != nil {
+		err = es.Encryption.ValidateKeyGeneration(app.state.Height)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+	}
+	if d.EndHeight != 0 && d.EndHeight <= app.state.Height {
+		return CodeTypeUnauthorized, errors.New("The poll's end height should be after the current height.")
+	}
+	if pj.RunoffThreshold > 0 && d.EndHeight == 0 {
+		return CodeTypeUnauthorized, errors.New("The poll with a runoff needs the end height.")
 	}
-	if !ver {
-		return CodeTypeUnauthorized, errors.New("The signature does not verify the data.")
+	has := app.state.HasPoll(d.PollHash)
+	if has || app.state.HasScheduledPoll(d.PollHash) {
+		return CodeTypeUnauthorized, errors.New("The poll's hash exists.")
+	}
+	if !app.state.IsLatestElection(d.ElectionID) {
+		return CodeTypeUnauthorized, errors.New("The election's ID is not the latest.")
+	}
+	return CodeTypeOK, nil
+}
+
+func (app *TVApplication) verifyDelivery(tvd TVDelivery) (uint32, error) {
+	// the anonymous vote's ring signature is verified with the election's voters
+	if tvd.Type != ANONYMOUS_VOTE {
+		ver, err := tvd.VerifySignature()
+		if err != nil {
+			return CodeTypeEncodingError, err
+		}
+		if !ver {
+			return CodeTypeUnauthorized, errors.New("The signature does not verify the data.")
+		}
 	}
 
 	switch tvd.Type {
@@ -27,6 +73,48 @@ func (app *TVApplication) verifyDelivery(tvd TVDelivery) (uint32, error) {
 		if err != nil {
 			return CodeTypeUnauthorized, err
 		}
+		err = d.ValidateVotersRoot()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateID()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateMetadata()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if d.Encryption != nil {
+			err = d.Encryption.Validate()
+			if err != nil {
+				return CodeTypeUnauthorized, err
+			}
+		}
+		err = d.ValidateAnonymous()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateCredentials()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateGroups()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateInitiativeShare()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateInvitations()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateAuditors()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
 		_, err = app.state.GetElection(d.ID)
 		if err == nil {
 			return CodeTypeUnauthorized, errors.New("The election's ID exists.")
@@ -37,23 +125,21 @@ func (app *TVApplication) verifyDelivery(tvd TVDelivery) (uint32, error) {
 		if err != nil {
 			return CodeTypeUnauthorized, err
 		}
-		_, err = app.state.GetElection(d.ElectionID)
+		es, err := app.state.GetElection(d.ElectionID)
 		if err != nil {
 			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
 		}
-		if len(d.PollHash) == 0 {
-			return CodeTypeUnauthorized, errors.New("Missing the IPFS hash for the poll.")
-		}
-		_, err = d.GetPollJsonFromPollHash()
+		err = es.ValidateStatus(ELECTION_OPEN)
 		if err != nil {
 			return CodeTypeUnauthorized, err
 		}
-		has := app.state.HasPoll(d.PollHash)
-		if has {
-			return CodeTypeUnauthorized, errors.New("The poll's hash exists.")
+		code, err := app.validateNewPoll(d, es)
+		if err != nil {
+			return code, err
 		}
-		if !app.state.IsLatestElection(d.ElectionID) {
-			return CodeTypeUnauthorized, errors.New("The election's ID is not the latest.")
+		err = d.ValidateActivation(app.state.Height, app.state.Time)
+		if err != nil {
+			return CodeTypeUnauthorized, err
 		}
 	case VOTE:
 		d := tvd.GetVoteDeliveryData()
@@ -68,19 +154,38 @@ func (app *TVApplication) verifyDelivery(tvd TVDelivery) (uint32, error) {
 		if err != nil {
 			return CodeTypeServerError, errors.New("Could not find the election from the poll that exists.")
 		}
-		foundVoter := false
-		for _, v := range es.Voters {
-			if v == d.From {
-				foundVoter = true
-				break
-			}
+		err = es.ValidateStatus(ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if ps.Cancelled != nil {
+			return CodeTypeUnauthorized, errors.New("The poll is cancelled.")
+		}
+		if ps.HasEnded(app.state.Height) {
+			return CodeTypeUnauthorized, errors.New("The poll has ended.")
+		}
+		err = ValidateNotPaused(es, ps)
+		if err != nil {
+			return CodeTypePaused, err
 		}
-		if !foundVoter {
+		if !es.IsVoter(d.From, d.Proof) {
 			return CodeTypeUnauthorized, errors.New("You don't exist in the list of voters.")
 		}
-		_, ok := ps.Choices[d.Choice]
-		if !ok {
-			return CodeTypeUnauthorized, errors.New("The choice " + d.Choice + " does not exists for poll " + d.PollHash + ".")
+		if len(ps.Groups) > 0 && len(es.VoterGroups(d.From, ps.Groups)) == 0 {
+			return CodeTypeUnauthorized, errors.New("You don't exist in the groups of the poll.")
+		}
+		if ps.Encrypted {
+			return CodeTypeUnauthorized, errors.New("The poll is encrypted, so the vote should be encrypted.")
+		}
+		if ps.Anonymous {
+			return CodeTypeUnauthorized, errors.New("The poll is anonymous, so the vote should be anonymous.")
+		}
+		if ps.Credentials {
+			return CodeTypeUnauthorized, errors.New("The poll needs a credential, so the vote should be with the credential's voting key.")
+		}
+		err = ps.ValidateAnswers(d)
+		if err != nil {
+			return CodeTypeUnauthorized, err
 		}
 		if app.state.HasVote(d) {
 			return CodeTypeUnauthorized, errors.New("You voted already for the specific poll.")
@@ -88,10 +193,726 @@ func (app *TVApplication) verifyDelivery(tvd TVDelivery) (uint32, error) {
 		if !app.state.IsLatestPoll(d.PollHash) {
 			return CodeTypeUnauthorized, errors.New("The poll's hash is not the latest.")
 		}
+	case ENCRYPTED_VOTE:
+		d := tvd.GetEncryptedVoteDeliveryData()
+		if len(d.PollHash) == 0 {
+			return CodeTypeUnauthorized, errors.New("The poll's hash is empty.")
+		}
+		ps, err := app.state.GetPoll(d.PollHash)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The poll's hash does not exists.")
+		}
+		if !ps.Encrypted {
+			return CodeTypeUnauthorized, errors.New("The poll is not encrypted.")
+		}
+		es, err := app.state.GetElection(ps.ElectionID)
+		if err != nil {
+			return CodeTypeServerError, errors.New("Could not find the election from the poll that exists.")
+		}
+		err = es.ValidateStatus(ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if ps.Cancelled != nil {
+			return CodeTypeUnauthorized, errors.New("The poll is cancelled.")
+		}
+		if ps.HasEnded(app.state.Height) {
+			return CodeTypeUnauthorized, errors.New("The poll has ended.")
+		}
+		err = ValidateNotPaused(es, ps)
+		if err != nil {
+			return CodeTypePaused, err
+		}
+		if !es.IsVoter(d.From, d.Proof) {
+			return CodeTypeUnauthorized, errors.New("You don't exist in the list of voters.")
+		}
+		if len(d.Ballot) != len(ps.EncryptedChoices) {
+			return CodeTypeUnauthorized, errors.New("The ballot should have a ciphertext for each choice of the poll.")
+		}
+		for k := range d.Ballot {
+			_, ok := ps.EncryptedChoices[k]
+			if !ok {
+				return CodeTypeUnauthorized, errors.New("The choice " + k + " does not exists for poll " + d.PollHash + ".")
+			}
+		}
+		err = es.Encryption.VerifyBallot(BallotContext(d.From, d.PollHash), d.EncryptedBallot, 1)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if app.state.HasVote(VoteDeliveryData{From: d.From, PollHash: d.PollHash}) {
+			return CodeTypeUnauthorized, errors.New("You voted already for the specific poll.")
+		}
+		if !app.state.IsLatestPoll(d.PollHash) {
+			return CodeTypeUnauthorized, errors.New("The poll's hash is not the latest.")
+		}
+		if len(ps.DecryptionShares) > 0 {
+			return CodeTypeUnauthorized, errors.New("The poll is closed, because the trustees started the decryption.")
+		}
+	case ANONYMOUS_VOTE:
+		d := tvd.GetAnonymousVoteDeliveryData()
+		if len(d.PollHash) == 0 {
+			return CodeTypeUnauthorized, errors.New("The poll's hash is empty.")
+		}
+		ps, err := app.state.GetPoll(d.PollHash)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The poll's hash does not exists.")
+		}
+		if !ps.Anonymous {
+			return CodeTypeUnauthorized, errors.New("The poll is not anonymous.")
+		}
+		es, err := app.state.GetElection(ps.ElectionID)
+		if err != nil {
+			return CodeTypeServerError, errors.New("Could not find the election from the poll that exists.")
+		}
+		err = es.ValidateStatus(ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if ps.Cancelled != nil {
+			return CodeTypeUnauthorized, errors.New("The poll is cancelled.")
+		}
+		if ps.HasEnded(app.state.Height) {
+			return CodeTypeUnauthorized, errors.New("The poll has ended.")
+		}
+		err = ValidateNotPaused(es, ps)
+		if err != nil {
+			return CodeTypePaused, err
+		}
+		b, err := tvd.GetDataInStructureOrder()
+		if err != nil {
+			return CodeTypeEncodingError, err
+		}
+		err = VerifyRing(es.Voters, d.PollHash, d.KeyImage, b, tvd.Signature)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = ps.ValidateAnswers(d.GetVoteDeliveryData())
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		// the key image takes the place of the voter's public key
+		if app.state.HasVote(VoteDeliveryData{From: d.KeyImage, PollHash: d.PollHash}) {
+			return CodeTypeUnauthorized, errors.New("You voted already for the specific poll.")
+		}
+		if !app.state.IsLatestPoll(d.PollHash) {
+			return CodeTypeUnauthorized, errors.New("The poll's hash is not the latest.")
+		}
+	case CREDENTIAL_REQUEST:
+		d := tvd.GetCredentialRequestDeliveryData()
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		if es.Credentials == nil {
+			return CodeTypeUnauthorized, errors.New("The election does not have credentials.")
+		}
+		err = es.ValidateStatus(ELECTION_DRAFT, ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if !es.IsVoter(d.From, d.Proof) {
+			return CodeTypeUnauthorized, errors.New("You are not a voter in the election.")
+		}
+		if app.state.HasCredential(d.ElectionID, d.From) {
+			return CodeTypeUnauthorized, errors.New("You requested already a credential for the election.")
+		}
+		err = es.Credentials.ValidateBlinded(d.Blinded)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+	case CREDENTIAL:
+		d := tvd.GetCredentialDeliveryData()
+		err := d.ValidateGonverment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		if es.Credentials == nil {
+			return CodeTypeUnauthorized, errors.New("The election does not have credentials.")
+		}
+		err = es.ValidateStatus(ELECTION_DRAFT, ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		cs, err := app.state.GetCredential(d.ElectionID, d.Voter)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if len(cs.BlindSignature) > 0 {
+			return CodeTypeUnauthorized, errors.New("The credential has been signed already.")
+		}
+		err = es.Credentials.VerifyBlindSignature(cs.Blinded, d.BlindSignature)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+	case CREDENTIAL_VOTE:
+		d := tvd.GetCredentialVoteDeliveryData()
+		if len(d.PollHash) == 0 {
+			return CodeTypeUnauthorized, errors.New("The poll's hash is empty.")
+		}
+		ps, err := app.state.GetPoll(d.PollHash)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The poll's hash does not exists.")
+		}
+		if !ps.Credentials {
+			return CodeTypeUnauthorized, errors.New("The poll does not need credentials.")
+		}
+		es, err := app.state.GetElection(ps.ElectionID)
+		if err != nil {
+			return CodeTypeServerError, errors.New("Could not find the election from the poll that exists.")
+		}
+		err = es.ValidateStatus(ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if ps.Cancelled != nil {
+			return CodeTypeUnauthorized, errors.New("The poll is cancelled.")
+		}
+		if ps.HasEnded(app.state.Height) {
+			return CodeTypeUnauthorized, errors.New("The poll has ended.")
+		}
+		err = ValidateNotPaused(es, ps)
+		if err != nil {
+			return CodeTypePaused, err
+		}
+		err = es.Credentials.VerifyCredential(CredentialMessage(es.ID, d.From), d.Credential)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = ps.ValidateAnswers(d.GetVoteDeliveryData())
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		// the credential is spent for the poll, when its voting key has voted
+		if app.state.HasVote(VoteDeliveryData{From: d.From, PollHash: d.PollHash}) {
+			return CodeTypeUnauthorized, errors.New("The credential has been spent already for the specific poll.")
+		}
+		// the gonverment could sign credentials outside of the chain, so the votes can not be more than the issued credentials
+		if len(ps.VotedAlready) >= es.IssuedCredentials {
+			return CodeTypeUnauthorized, errors.New("The poll has a vote for each issued credential already.")
+		}
+		if !app.state.IsLatestPoll(d.PollHash) {
+			return CodeTypeUnauthorized, errors.New("The poll's hash is not the latest.")
+		}
+	case ADD_VOTERS, REMOVE_VOTERS:
+		d := tvd.GetVotersDeliveryData()
+		err := d.ValidateGonverment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		if len(es.VotersRoot) > 0 {
+			return CodeTypeUnauthorized, errors.New("The election with the voters' root can not change its voters.")
+		}
+		err = es.ValidateStatus(ELECTION_DRAFT)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateVoters()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if tvd.Type == REMOVE_VOTERS && len(d.Groups) > 0 {
+			return CodeTypeUnauthorized, errors.New("The removed voters can not have groups.")
+		}
+		if len(d.Groups) > 0 && (es.Encryption != nil || es.Anonymous || es.Credentials != nil) {
+			return CodeTypeUnauthorized, errors.New("The election with groups can not be encrypted, anonymous or with credentials, because the votes are counted for each group.")
+		}
+		err = ValidateVoterGroups(d.Voters, d.Groups)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		for _, v := range d.Voters {
+			if tvd.Type == ADD_VOTERS && es.HasVoter(v) {
+				return CodeTypeUnauthorized, errors.New("The voter " + v + " exists already in the election.")
+			}
+			if tvd.Type == REMOVE_VOTERS && !es.HasVoter(v) {
+				return CodeTypeUnauthorized, errors.New("The voter " + v + " does not exist in the election.")
+			}
+		}
+		if tvd.Type == ADD_VOTERS && es.Anonymous {
+			for _, v := range d.Voters {
+				_, err := ringPublicKey(v)
+				if err != nil {
+					return CodeTypeUnauthorized, errors.New("The voter " + v + " can not be in an anonymous election: " + err.Error())
+				}
+			}
+		}
+	case ELECTION_STATUS:
+		d := tvd.GetElectionStatusDeliveryData()
+		err := d.ValidateGonverment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		if !es.Status.CanChangeTo(d.Status) {
+			return CodeTypeUnauthorized, errors.New("The election can not change from " + string(es.Status) + " to " + string(d.Status) + ".")
+		}
+	case MERGE_WRITE_INS:
+		d := tvd.GetMergeWriteInsDeliveryData()
+		err := d.ValidateGonverment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		ps, err := app.state.GetPoll(d.PollHash)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The poll's hash does not exists.")
+		}
+		if !ps.AllowWriteIns {
+			return CodeTypeUnauthorized, errors.New("The poll does not have write-ins.")
+		}
+		if ps.Cancelled != nil {
+			return CodeTypeUnauthorized, errors.New("The poll is cancelled.")
+		}
+		if !app.state.IsPollClosed(ps) {
+			return CodeTypeUnauthorized, errors.New("The write-ins can be merged only after the poll closes.")
+		}
+		if len(d.Variants) == 0 {
+			return CodeTypeUnauthorized, errors.New("The variants are empty.")
+		}
+		_, isChoice := ps.Choices[d.Candidate]
+		if !isChoice {
+			err = ValidateWriteIn(d.Candidate)
+			if err != nil {
+				return CodeTypeUnauthorized, errors.New("The candidate is not a choice or a correct write-in: " + err.Error())
+			}
+		}
+		variants := map[string]bool{}
+		for _, v := range d.Variants {
+			_, ok := ps.WriteIns[v]
+			if !ok {
+				return CodeTypeUnauthorized, errors.New("The write-in " + v + " does not exist in the poll.")
+			}
+			if variants[v] || v == d.Candidate {
+				return CodeTypeUnauthorized, errors.New("The write-in " + v + " can not be merged twice or into itself.")
+			}
+			variants[v] = true
+		}
+	case INITIATIVE:
+		d := tvd.GetInitiativeDeliveryData()
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		err = es.ValidateStatus(ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if es.InitiativeShare == 0 {
+			return CodeTypeUnauthorized, errors.New("The election does not accept initiatives.")
+		}
+		if es.Paused != nil {
+			return CodeTypePaused, errors.New("The election is paused: " + es.Paused.Reason)
+		}
+		if !es.IsVoter(d.From, d.Proof) {
+			return CodeTypeUnauthorized, errors.New("You don't exist in the list of voters.")
+		}
+		_, err = app.state.GetInitiative(d.PollHash)
+		if err == nil {
+			return CodeTypeUnauthorized, errors.New("The initiative exists already.")
+		}
+		code, err := app.validateNewPoll(PollDeliveryData{From: d.From, ElectionID: d.ElectionID, PollHash: d.PollHash}, es)
+		if err != nil {
+			return code, err
+		}
+	case SUPPORT:
+		d := tvd.GetSupportDeliveryData()
+		is, err := app.state.GetInitiative(d.PollHash)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The initiative does not exists.")
+		}
+		if is.Created {
+			return CodeTypeUnauthorized, errors.New("The initiative is a poll already.")
+		}
+		es, err := app.state.GetElection(is.ElectionID)
+		if err != nil {
+			return CodeTypeServerError, errors.New("Could not find the election from the initiative that exists.")
+		}
+		err = es.ValidateStatus(ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if !es.IsVoter(d.From, d.Proof) {
+			return CodeTypeUnauthorized, errors.New("You don't exist in the list of voters.")
+		}
+		if es.Paused != nil {
+			return CodeTypePaused, errors.New("The election is paused: " + es.Paused.Reason)
+		}
+		if is.HasSupporter(d.From) {
+			return CodeTypeUnauthorized, errors.New("You support the initiative already.")
+		}
+		// the poll is created with the last support, so it should be valid for the election at this height
+		if len(is.Supporters)+1 >= is.NeededSupporters(es) {
+			code, err := app.validateNewPoll(PollDeliveryData{From: is.Proposer, ElectionID: is.ElectionID, PollHash: is.PollHash}, es)
+			if err != nil {
+				return code, err
+			}
+		}
+	case REGISTRATION:
+		d := tvd.GetRegistrationDeliveryData()
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		if len(es.VotersRoot) > 0 {
+			return CodeTypeUnauthorized, errors.New("The election with the voters' root can not change its voters.")
+		}
+		err = es.ValidateStatus(ELECTION_DRAFT)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if es.HasVoter(d.From) {
+			return CodeTypeUnauthorized, errors.New("You exist already in the election.")
+		}
+		rs, err := app.state.GetRegistration(d.ElectionID, d.From)
+		if err == nil && rs.Status == REGISTRATION_PENDING {
+			return CodeTypeUnauthorized, errors.New("Your registration is pending already.")
+		}
+		if es.Anonymous {
+			_, err := ringPublicKey(d.From)
+			if err != nil {
+				return CodeTypeUnauthorized, errors.New("You can not be in an anonymous election: " + err.Error())
+			}
+		}
+		err = d.ValidateAttributes()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+	case APPROVE, REJECT:
+		d := tvd.GetRegistrationDecisionDeliveryData()
+		err := d.ValidateGonverment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		err = es.ValidateStatus(ELECTION_DRAFT)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		rs, err := app.state.GetRegistration(d.ElectionID, d.Voter)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if rs.Status != REGISTRATION_PENDING {
+			return CodeTypeUnauthorized, errors.New("The registration of the voter " + d.Voter + " is " + string(rs.Status) + " already.")
+		}
+		if tvd.Type == APPROVE && es.HasVoter(d.Voter) {
+			return CodeTypeUnauthorized, errors.New("The voter " + d.Voter + " exists already in the election.")
+		}
+		if tvd.Type == APPROVE && len(d.Reason) > 0 {
+			return CodeTypeUnauthorized, errors.New("Only the rejection has a reason.")
+		}
+		err = d.ValidateReason()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+	case REPLACE_KEY:
+		d := tvd.GetReplaceKeyDeliveryData()
+		err := d.ValidateGonverment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		if len(es.VotersRoot) > 0 {
+			return CodeTypeUnauthorized, errors.New("The election with the voters' root can not change its voters.")
+		}
+		// the ring signature does not show which voter voted, so the votes of the old key can not move to the new key
+		if es.Anonymous {
+			return CodeTypeUnauthorized, errors.New("The anonymous election can not replace the voters' keys.")
+		}
+		err = es.ValidateStatus(ELECTION_DRAFT, ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if !es.HasVoter(d.OldVoter) {
+			return CodeTypeUnauthorized, errors.New("The voter " + d.OldVoter + " does not exist in the election.")
+		}
+		err = validatePublicKey(d.NewVoter)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The voter " + d.NewVoter + " has not a correct public key: " + err.Error())
+		}
+		if es.HasVoter(d.NewVoter) {
+			return CodeTypeUnauthorized, errors.New("The voter " + d.NewVoter + " exists already in the election.")
+		}
+		if app.state.HasCredential(d.ElectionID, d.NewVoter) {
+			return CodeTypeUnauthorized, errors.New("The voter " + d.NewVoter + " has requested a credential already.")
+		}
+	case REDEEM_INVITATION:
+		d := tvd.GetRedeemInvitationDeliveryData()
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		// the voters' list is fixed when the election opens
+		err = es.ValidateStatus(ELECTION_DRAFT)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		voter, ok := es.Invitations[InvitationHash(d.Code)]
+		if !ok {
+			return CodeTypeUnauthorized, errors.New("The invitation's code does not exist in the election.")
+		}
+		if len(voter) > 0 {
+			return CodeTypeUnauthorized, errors.New("The invitation's code has been spent already.")
+		}
+		if es.HasVoter(d.From) {
+			return CodeTypeUnauthorized, errors.New("You exist already in the election.")
+		}
+		err = es.ValidateInvitationCommitment(d.From, d.Code)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if es.Anonymous {
+			_, err := ringPublicKey(d.From)
+			if err != nil {
+				return CodeTypeUnauthorized, errors.New("You can not be in an anonymous election: " + err.Error())
+			}
+		}
+	case COMMIT_INVITATION:
+		d := tvd.GetCommitInvitationDeliveryData()
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		err = es.ValidateStatus(ELECTION_DRAFT)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if len(es.Invitations) == 0 {
+			return CodeTypeUnauthorized, errors.New("The election does not have invitations.")
+		}
+		err = d.ValidateCommitment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if es.HasVoter(d.From) {
+			return CodeTypeUnauthorized, errors.New("You exist already in the election.")
+		}
+	case EMBARGO_OVERRIDE:
+		d := tvd.GetEmbargoOverrideDeliveryData()
+		ps, err := app.state.GetPoll(d.PollHash)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The poll's hash does not exists.")
+		}
+		es, err := app.state.GetElection(ps.ElectionID)
+		if err != nil {
+			return CodeTypeServerError, errors.New("Could not find the election from the poll that exists.")
+		}
+		err = d.ValidateGonvermentOrAuditor(es)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if !app.state.IsEmbargoed(ps) {
+			return CodeTypeUnauthorized, errors.New("The poll's results are not under embargo.")
+		}
+		err = d.ValidateReason()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+	case CANCEL_POLL:
+		d := tvd.GetCancelPollDeliveryData()
+		err := d.ValidateGonverment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = d.ValidateReason()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		ps, err := app.state.GetPoll(d.PollHash)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The poll's hash does not exists.")
+		}
+		if ps.Cancelled != nil {
+			return CodeTypeUnauthorized, errors.New("The poll is cancelled already.")
+		}
+		es, err := app.state.GetElection(ps.ElectionID)
+		if err != nil {
+			return CodeTypeServerError, errors.New("Could not find the election from the poll that exists.")
+		}
+		err = es.ValidateStatus(ELECTION_OPEN, ELECTION_CLOSED)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if len(d.Replacement) > 0 {
+			rps, err := app.state.GetPoll(d.Replacement)
+			if err != nil {
+				return CodeTypeUnauthorized, errors.New("The replacement poll does not exists.")
+			}
+			if rps.ElectionID != ps.ElectionID || rps.PollHash == ps.PollHash || rps.Cancelled != nil {
+				return CodeTypeUnauthorized, errors.New("The replacement should be another poll of the same election, that is not cancelled.")
+			}
+		}
+	case PAUSE, RESUME:
+		d := tvd.GetPauseDeliveryData()
+		err := d.ValidateGonverment()
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if (len(d.ElectionID) == 0) == (len(d.PollHash) == 0) {
+			return CodeTypeUnauthorized, errors.New("The pause should be for an election or a poll.")
+		}
+		var paused *PauseState
+		if len(d.PollHash) > 0 {
+			ps, err := app.state.GetPoll(d.PollHash)
+			if err != nil {
+				return CodeTypeUnauthorized, errors.New("The poll's hash does not exists.")
+			}
+			if ps.Cancelled != nil {
+				return CodeTypeUnauthorized, errors.New("The poll is cancelled.")
+			}
+			d.ElectionID = ps.ElectionID
+			paused = ps.Paused
+		}
+		es, err := app.state.GetElection(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The election's ID does not exists.")
+		}
+		err = es.ValidateStatus(ELECTION_OPEN)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		if len(d.PollHash) == 0 {
+			paused = es.Paused
+		}
+		if tvd.Type == PAUSE {
+			if paused != nil {
+				return CodeTypeUnauthorized, errors.New("It is paused already.")
+			}
+			err = d.ValidateReason()
+			if err != nil {
+				return CodeTypeUnauthorized, err
+			}
+		}
+		if tvd.Type == RESUME && paused == nil {
+			return CodeTypeUnauthorized, errors.New("It is not paused.")
+		}
+	case DECRYPTION:
+		d := tvd.GetDecryptionDeliveryData()
+		ps, err := app.state.GetPoll(d.PollHash)
+		if err != nil {
+			return CodeTypeUnauthorized, errors.New("The poll's hash does not exists.")
+		}
+		if !ps.Encrypted {
+			return CodeTypeUnauthorized, errors.New("The poll is not encrypted.")
+		}
+		if ps.Tallied {
+			return CodeTypeUnauthorized, errors.New("The poll is tallied already.")
+		}
+		es, err := app.state.GetElection(ps.ElectionID)
+		if err != nil {
+			return CodeTypeServerError, errors.New("Could not find the election from the poll that exists.")
+		}
+		err = es.ValidateStatus(ELECTION_OPEN, ELECTION_CLOSED)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		// a trustee can not stop the votes of a poll, so the decryption starts only after the poll closes
+		if !app.state.IsPollClosed(ps) {
+			return CodeTypeUnauthorized, errors.New("The poll accepts votes, so it can not be decrypted yet.")
+		}
+		trustee, ok := es.Encryption.GetTrustee(d.From)
+		if !ok {
+			return CodeTypeUnauthorized, errors.New("You are not a trustee of the election.")
+		}
+		_, ok = ps.DecryptionShares[d.From]
+		if ok {
+			return CodeTypeUnauthorized, errors.New("You decrypted already the specific poll.")
+		}
+		if len(d.Shares) != len(ps.EncryptedChoices) {
+			return CodeTypeUnauthorized, errors.New("The decryption should have a share for each choice of the poll.")
+		}
+		for k, v := range d.Shares {
+			c, ok := ps.EncryptedChoices[k]
+			if !ok {
+				return CodeTypeUnauthorized, errors.New("The choice " + k + " does not exists for poll " + d.PollHash + ".")
+			}
+			err = es.Encryption.VerifyDecryptionShare(*trustee, c, v)
+			if err != nil {
+				return CodeTypeUnauthorized, errors.New("The share for the choice " + k + " is not correct: " + err.Error())
+			}
+		}
+	case KEY_DEALING:
+		d := tvd.GetKeyDealingDeliveryData()
+		es, err := app.validateKeyGeneration(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		trustee, ok := es.Encryption.GetTrustee(d.From)
+		if !ok {
+			return CodeTypeUnauthorized, errors.New("You are not a trustee of the election.")
+		}
+		if trustee.Dealing != nil {
+			return CodeTypeUnauthorized, errors.New("You dealt already the election's key.")
+		}
+		err = es.Encryption.ValidateDealing(d.Dealing)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+	case KEY_COMPLAINT:
+		d := tvd.GetKeyComplaintDeliveryData()
+		es, err := app.validateKeyGeneration(d.ElectionID)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		err = es.Encryption.ValidateComplaintPeriod(app.state.Height)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
+		complainer, ok := es.Encryption.GetTrustee(d.From)
+		if !ok {
+			return CodeTypeUnauthorized, errors.New("You are not a trustee of the election.")
+		}
+		dealer, ok := es.Encryption.GetTrustee(d.Dealer)
+		if !ok || d.Dealer == d.From {
+			return CodeTypeUnauthorized, errors.New("The dealer is not another trustee of the election.")
+		}
+		if dealer.Dealing != nil && dealer.Dealing.Disqualified {
+			return CodeTypeUnauthorized, errors.New("The dealer is disqualified already.")
+		}
+		err = es.Encryption.VerifyComplaint(*complainer, *dealer, d.Key, d.Proof)
+		if err != nil {
+			return CodeTypeUnauthorized, err
+		}
 	}
 	return CodeTypeOK, nil
 }
 
+// validateKeyGeneration returns the election that its trustees generate the key,
+// until the first poll after the complaints' period finishes the key
+func (app *TVApplication) validateKeyGeneration(electionID string) (*ElectionState, error) {
+	es, err := app.state.GetElection(electionID)
+	if err != nil {
+		return nil, err
+	}
+	if es.Encryption == nil {
+		return nil, errors.New("The election is not encrypted.")
+	}
+	err = es.ValidateStatus(ELECTION_DRAFT, ELECTION_OPEN)
+	if err != nil {
+		return nil, err
+	}
+	if es.Encryption.IsKeyFinished() {
+		return nil, errors.New("The election's key is finished.")
+	}
+	return es, nil
+}
+
 func (app *TVApplication) DeliverTx(tx []byte) types.ResponseDeliverTx {
 	tvd := TVDelivery{}
 	json.Unmarshal(tx, &tvd)
@@ -107,11 +928,141 @@ func (app *TVApplication) DeliverTx(tx []byte) types.ResponseDeliverTx {
 		app.state.CreateElection(d)
 	case POLL:
 		d := tvd.GetPollDeliveryData()
-		app.state.CreatePoll(d)
+		var err error
+		if d.IsScheduled() {
+			err = app.state.SchedulePoll(d)
+		} else {
+			err = app.state.CreatePoll(d)
+		}
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
 	case VOTE:
 		d := tvd.GetVoteDeliveryData()
 		app.state.CreateVote(d)
 		app.state.AddVoteToThePoll(d)
+	case ENCRYPTED_VOTE:
+		d := tvd.GetEncryptedVoteDeliveryData()
+		app.state.CreateVote(VoteDeliveryData{From: d.From, PollHash: d.PollHash})
+		app.state.AddEncryptedVoteToThePoll(d)
+	case ANONYMOUS_VOTE:
+		d := tvd.GetAnonymousVoteDeliveryData()
+		vd := d.GetVoteDeliveryData()
+		app.state.CreateVote(vd)
+		app.state.AddVoteToThePoll(vd)
+	case CREDENTIAL_REQUEST:
+		d := tvd.GetCredentialRequestDeliveryData()
+		app.state.CreateCredentialRequest(d)
+	case CREDENTIAL:
+		d := tvd.GetCredentialDeliveryData()
+		err := app.state.AddBlindSignatureToTheCredential(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case CREDENTIAL_VOTE:
+		d := tvd.GetCredentialVoteDeliveryData()
+		vd := d.GetVoteDeliveryData()
+		app.state.CreateVote(vd)
+		app.state.AddVoteToThePoll(vd)
+	case ADD_VOTERS:
+		d := tvd.GetVotersDeliveryData()
+		err := app.state.AddVotersToTheElection(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case REMOVE_VOTERS:
+		d := tvd.GetVotersDeliveryData()
+		err := app.state.RemoveVotersFromTheElection(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case ELECTION_STATUS:
+		d := tvd.GetElectionStatusDeliveryData()
+		err := app.state.ChangeElectionStatus(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case MERGE_WRITE_INS:
+		d := tvd.GetMergeWriteInsDeliveryData()
+		err := app.state.MergeWriteIns(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case INITIATIVE:
+		d := tvd.GetInitiativeDeliveryData()
+		err := app.state.CreateInitiative(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case SUPPORT:
+		d := tvd.GetSupportDeliveryData()
+		err := app.state.SupportInitiative(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case REGISTRATION:
+		d := tvd.GetRegistrationDeliveryData()
+		app.state.RequestRegistration(d)
+	case APPROVE, REJECT:
+		d := tvd.GetRegistrationDecisionDeliveryData()
+		err := app.state.DecideRegistration(d, tvd.Type == APPROVE)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case REPLACE_KEY:
+		d := tvd.GetReplaceKeyDeliveryData()
+		err := app.state.ReplaceVoterKey(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case REDEEM_INVITATION:
+		d := tvd.GetRedeemInvitationDeliveryData()
+		err := app.state.RedeemInvitation(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case COMMIT_INVITATION:
+		d := tvd.GetCommitInvitationDeliveryData()
+		err := app.state.CommitInvitation(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case EMBARGO_OVERRIDE:
+		d := tvd.GetEmbargoOverrideDeliveryData()
+		err := app.state.OverrideEmbargo(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case CANCEL_POLL:
+		d := tvd.GetCancelPollDeliveryData()
+		err := app.state.CancelPoll(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case PAUSE, RESUME:
+		d := tvd.GetPauseDeliveryData()
+		err := app.state.ChangePause(d, tvd.Type == PAUSE)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case DECRYPTION:
+		d := tvd.GetDecryptionDeliveryData()
+		err := app.state.AddDecryptionToThePoll(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case KEY_DEALING:
+		d := tvd.GetKeyDealingDeliveryData()
+		err := app.state.AddKeyDealing(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
+	case KEY_COMPLAINT:
+		d := tvd.GetKeyComplaintDeliveryData()
+		err := app.state.DisqualifyKeyDealing(d)
+		if err != nil {
+			return types.ResponseDeliverTx{Code: CodeTypeServerError, Log: err.Error()}
+		}
 	}
 	return types.ResponseDeliverTx{Code: CodeTypeOK}
 }
@@ -0,0 +1,239 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/mragiadakos/tendervoting/server/confs"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestVoters(t *testing.T, n int) ([]crypto.PrivKey, []string) {
+	voters := []crypto.PrivKey{}
+	voterHexs := []string{}
+	for i := 0; i < n; i++ {
+		privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		assert.Nil(t, err)
+		pubB, _ := privk.GetPublic().Bytes()
+		voters = append(voters, privk)
+		voterHexs = append(voterHexs, hex.EncodeToString(pubB))
+	}
+	return voters, voterHexs
+}
+
+func TestAnonymousElectionFailOnEncryption(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 2)
+	_, _, enc := forTestTrustees(t, 1, 1)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	ed := ElectionDeliveryData{}
+	ed.ID = uuid.NewV4().String()
+	ed.From = hex.EncodeToString(pubB)
+	ed.Voters = voterHexs
+	ed.Encryption = enc
+	ed.Anonymous = true
+	confs.Conf.GonvermentPublicKeyHex = ed.From
+
+	b, _ := json.Marshal(ed)
+	sign, err := privk.Sign(b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = ELECTION
+	tvd.Signature = sign
+	tvd.Data = &ed
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	assert.Equal(t, CodeTypeUnauthorized, resp.Code)
+}
+
+func TestAnonymousElectionFailOnRSAVoter(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	_, rsaPub, err := crypto.GenerateKeyPairWithReader(crypto.RSA, 1024, rand.Reader)
+	assert.Nil(t, err)
+	rsaPubB, _ := rsaPub.Bytes()
+
+	pubB, _ := privk.GetPublic().Bytes()
+	ed := ElectionDeliveryData{}
+	ed.ID = uuid.NewV4().String()
+	ed.From = hex.EncodeToString(pubB)
+	ed.Voters = append(voterHexs, hex.EncodeToString(rsaPubB))
+	ed.Anonymous = true
+	confs.Conf.GonvermentPublicKeyHex = ed.From
+
+	b, _ := json.Marshal(ed)
+	sign, err := privk.Sign(b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = ELECTION
+	tvd.Signature = sign
+	tvd.Data = &ed
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	assert.Equal(t, CodeTypeUnauthorized, resp.Code)
+}
+
+func TestAnonymousVoteFailOnNonAnonymousPoll(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	code := forTestCreateAnonymousVote(t, app, voters[0], voterHexs, pollHash, "a")
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestAnonymousVoteFailOnPlainVote(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateAnonymousElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	vd := VoteDeliveryData{}
+	vd.From = voterHexs[0]
+	vd.PollHash = pollHash
+	vd.Choice = "a"
+	b, _ := json.Marshal(vd)
+	sign, err := voters[0].Sign(b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = VOTE
+	tvd.Signature = sign
+	tvd.Data = &vd
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	assert.Equal(t, CodeTypeUnauthorized, resp.Code)
+}
+
+func TestAnonymousVoteFailOnVoterNotInTheRing(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 3)
+	others, otherHexs := forTestVoters(t, 1)
+	electionID := forTestCreateAnonymousElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	// the outsider signs with a ring that has its key instead of a voter's key
+	ring := []string{voterHexs[0], voterHexs[1], otherHexs[0]}
+	code := forTestCreateAnonymousVote(t, app, others[0], ring, pollHash, "a")
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestAnonymousVoteFailOnChangedChoice(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateAnonymousElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	keyImage, err := RingKeyImage(voters[0], pollHash)
+	assert.Nil(t, err)
+	vd := AnonymousVoteDeliveryData{PollHash: pollHash, Choice: "a", KeyImage: keyImage}
+	b, _ := json.Marshal(vd)
+	sign, err := SignRing(voters[0], voterHexs, pollHash, b)
+	assert.Nil(t, err)
+	vd.Choice = "b"
+
+	tvd := TVDelivery{}
+	tvd.Type = ANONYMOUS_VOTE
+	tvd.Signature = sign
+	tvd.Data = &vd
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	assert.Equal(t, CodeTypeUnauthorized, resp.Code)
+}
+
+func TestAnonymousVoteFailOnVotingTwice(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateAnonymousElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	code := forTestCreateAnonymousVote(t, app, voters[1], voterHexs, pollHash, "a")
+	assert.Equal(t, CodeTypeOK, code)
+	// the new signature has different randomness but the same key image
+	code = forTestCreateAnonymousVote(t, app, voters[1], voterHexs, pollHash, "b")
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestAnonymousVoteFailOnVotingTwiceWithUppercaseKeyImage(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateAnonymousElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	assert.Equal(t, CodeTypeOK, forTestCreateAnonymousVote(t, app, voters[1], voterHexs, pollHash, "a"))
+	// the same key image in another encoding
+	keyImage, err := RingKeyImage(voters[1], pollHash)
+	assert.Nil(t, err)
+	vd := AnonymousVoteDeliveryData{PollHash: pollHash, Choice: "a", KeyImage: strings.ToUpper(keyImage)}
+	b, _ := json.Marshal(vd)
+	sign, err := SignRing(voters[1], voterHexs, pollHash, b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = ANONYMOUS_VOTE
+	tvd.Signature = sign
+	tvd.Data = &vd
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	assert.Equal(t, CodeTypeUnauthorized, resp.Code)
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pvq.NumberOfVotes)
+}
+
+func TestAnonymousVoteSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateAnonymousElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	assert.Equal(t, CodeTypeOK, forTestCreateAnonymousVote(t, app, voters[0], voterHexs, pollHash, "a"))
+	assert.Equal(t, CodeTypeOK, forTestCreateAnonymousVote(t, app, voters[1], voterHexs, pollHash, "b"))
+	assert.Equal(t, CodeTypeOK, forTestCreateAnonymousVote(t, app, voters[2], voterHexs, pollHash, "a"))
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, pvq.NumberOfVotes)
+	assert.Equal(t, 2, pvq.Choices["a"])
+	assert.Equal(t, 1, pvq.Choices["b"])
+
+	// the poll's state does not have the voters' public keys
+	ps, err := app.state.GetPoll(pollHash)
+	assert.Nil(t, err)
+	for _, v := range voterHexs {
+		assert.NotContains(t, ps.VotedAlready, v)
+	}
+}
@@ -0,0 +1,74 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestCancelPoll(t *testing.T, app *TVApplication, privk crypto.PrivKey, cd CancelPollDeliveryData) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+	cd.From = hex.EncodeToString(pubB)
+	return forTestDeliver(t, app, privk, CANCEL_POLL, &cd)
+}
+
+func TestCancelPollFailOnWrongDelivery(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice"})
+	otherElectionID := forTestCreateElection(t, app, gov, voterHexs)
+	otherPollHash := forTestCreatePoll(t, app, gov, otherElectionID, map[string]string{"b": "bob"})
+
+	// not the gonverment
+	assert.Equal(t, CodeTypeUnauthorized, forTestCancelPoll(t, app, voters[0], CancelPollDeliveryData{PollHash: pollHash, Reason: "wrong option"}))
+	// without reason
+	assert.Equal(t, CodeTypeUnauthorized, forTestCancelPoll(t, app, gov, CancelPollDeliveryData{PollHash: pollHash}))
+	// the replacement from another election
+	assert.Equal(t, CodeTypeUnauthorized, forTestCancelPoll(t, app, gov, CancelPollDeliveryData{PollHash: pollHash, Reason: "wrong option", Replacement: otherPollHash}))
+	// the replacement is the same poll
+	assert.Equal(t, CodeTypeUnauthorized, forTestCancelPoll(t, app, gov, CancelPollDeliveryData{PollHash: pollHash, Reason: "wrong option", Replacement: pollHash}))
+
+	assert.Equal(t, CodeTypeOK, forTestCancelPoll(t, app, gov, CancelPollDeliveryData{PollHash: pollHash, Reason: "wrong option"}))
+	// cancel twice
+	assert.Equal(t, CodeTypeUnauthorized, forTestCancelPoll(t, app, gov, CancelPollDeliveryData{PollHash: pollHash, Reason: "wrong option"}))
+}
+
+func TestCancelPollSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice", "b": "bobb"})
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "b"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+
+	replacement := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice", "b": "bob"})
+	app.state.Height = 9
+	cd := CancelPollDeliveryData{PollHash: pollHash, Reason: "the name of bob is wrong", Replacement: replacement}
+	assert.Equal(t, CodeTypeOK, forTestCancelPoll(t, app, gov, cd))
+
+	// the original tally is kept, but it is void
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.True(t, pvq.Void)
+	assert.Equal(t, 1, pvq.Choices["b"])
+	assert.Equal(t, &CancelState{Reason: "the name of bob is wrong", Replacement: replacement, Height: 9}, pvq.Cancelled)
+
+	list := app.queryListPolls()
+	for _, v := range list {
+		assert.Equal(t, v.PollHash == pollHash, v.Void)
+		if v.Void {
+			assert.Equal(t, replacement, v.Replacement)
+		}
+	}
+
+	vd = VoteDeliveryData{From: voterHexs[1], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[1], VOTE, &vd))
+}
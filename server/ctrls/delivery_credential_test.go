@@ -0,0 +1,206 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+var forTestCredentialPrivateKey *CredentialPrivateKey
+
+func forTestCredentialKey(t *testing.T) *CredentialPrivateKey {
+	if forTestCredentialPrivateKey == nil {
+		cpk, err := NewCredentialKey(2048)
+		assert.Nil(t, err)
+		forTestCredentialPrivateKey = cpk
+	}
+	return forTestCredentialPrivateKey
+}
+
+// forTestIssueCredential requests and issues a credential for the voter, and returns the voting key with its credential
+func forTestIssueCredential(t *testing.T, app *TVApplication, gov, voter crypto.PrivKey, cpk *CredentialPrivateKey, electionID string) (crypto.PrivKey, string) {
+	votingKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	votingPubB, _ := votingKey.GetPublic().Bytes()
+	msg := CredentialMessage(electionID, hex.EncodeToString(votingPubB))
+	blinded, r, err := cpk.Blind(msg)
+	assert.Nil(t, err)
+
+	voterPubB, _ := voter.GetPublic().Bytes()
+	crd := CredentialRequestDeliveryData{From: hex.EncodeToString(voterPubB), ElectionID: electionID, Blinded: blinded}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voter, CREDENTIAL_REQUEST, &crd))
+
+	blindSignature, err := cpk.SignBlinded(blinded)
+	assert.Nil(t, err)
+	govPubB, _ := gov.GetPublic().Bytes()
+	cd := CredentialDeliveryData{From: hex.EncodeToString(govPubB), ElectionID: electionID, Voter: crd.From, BlindSignature: blindSignature}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, gov, CREDENTIAL, &cd))
+
+	credential, err := cpk.Unblind(blindSignature, r)
+	assert.Nil(t, err)
+	return votingKey, credential
+}
+
+func forTestCreateCredentialVote(t *testing.T, app *TVApplication, votingKey crypto.PrivKey, credential, poll, choice string) uint32 {
+	pubB, _ := votingKey.GetPublic().Bytes()
+	cvd := CredentialVoteDeliveryData{From: hex.EncodeToString(pubB), PollHash: poll, Choice: choice, Credential: credential}
+	return forTestDeliver(t, app, votingKey, CREDENTIAL_VOTE, &cvd)
+}
+
+func TestCredentialRequestFailOnNonVoter(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	cpk := forTestCredentialKey(t)
+	_, voterHexs := forTestVoters(t, 2)
+	others, otherHexs := forTestVoters(t, 1)
+	electionID := forTestCreateCredentialElection(t, app, gov, voterHexs, &cpk.CredentialKey)
+
+	blinded, _, err := cpk.Blind(CredentialMessage(electionID, otherHexs[0]))
+	assert.Nil(t, err)
+	crd := CredentialRequestDeliveryData{From: otherHexs[0], ElectionID: electionID, Blinded: blinded}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, others[0], CREDENTIAL_REQUEST, &crd))
+}
+
+func TestCredentialRequestFailOnSecondRequest(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	cpk := forTestCredentialKey(t)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateCredentialElection(t, app, gov, voterHexs, &cpk.CredentialKey)
+
+	forTestIssueCredential(t, app, gov, voters[0], cpk, electionID)
+	blinded, _, err := cpk.Blind(CredentialMessage(electionID, "anotherkey"))
+	assert.Nil(t, err)
+	crd := CredentialRequestDeliveryData{From: voterHexs[0], ElectionID: electionID, Blinded: blinded}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], CREDENTIAL_REQUEST, &crd))
+}
+
+func TestCredentialFailOnWrongBlindSignature(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	cpk := forTestCredentialKey(t)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateCredentialElection(t, app, gov, voterHexs, &cpk.CredentialKey)
+
+	blinded, _, err := cpk.Blind(CredentialMessage(electionID, "votingkey"))
+	assert.Nil(t, err)
+	crd := CredentialRequestDeliveryData{From: voterHexs[0], ElectionID: electionID, Blinded: blinded}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], CREDENTIAL_REQUEST, &crd))
+
+	// the gonverment signs a different message from the blinded one
+	otherBlinded, _, err := cpk.Blind(CredentialMessage(electionID, "otherkey"))
+	assert.Nil(t, err)
+	blindSignature, err := cpk.SignBlinded(otherBlinded)
+	assert.Nil(t, err)
+	govPubB, _ := gov.GetPublic().Bytes()
+	cd := CredentialDeliveryData{From: hex.EncodeToString(govPubB), ElectionID: electionID, Voter: voterHexs[0], BlindSignature: blindSignature}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, gov, CREDENTIAL, &cd))
+}
+
+func TestCredentialVoteFailOnPlainVote(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	cpk := forTestCredentialKey(t)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateCredentialElection(t, app, gov, voterHexs, &cpk.CredentialKey)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a", "b": "b"})
+
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+}
+
+func TestCredentialVoteFailOnForgedCredential(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	cpk := forTestCredentialKey(t)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateCredentialElection(t, app, gov, voterHexs, &cpk.CredentialKey)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a", "b": "b"})
+
+	_, credential := forTestIssueCredential(t, app, gov, voters[0], cpk, electionID)
+	// the credential is for another voting key
+	otherKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	assert.Equal(t, CodeTypeUnauthorized, forTestCreateCredentialVote(t, app, otherKey, credential, pollHash, "a"))
+}
+
+func TestCredentialVoteFailOnSpentCredential(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	cpk := forTestCredentialKey(t)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateCredentialElection(t, app, gov, voterHexs, &cpk.CredentialKey)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a", "b": "b"})
+
+	votingKey, credential := forTestIssueCredential(t, app, gov, voters[0], cpk, electionID)
+	assert.Equal(t, CodeTypeOK, forTestCreateCredentialVote(t, app, votingKey, credential, pollHash, "a"))
+	assert.Equal(t, CodeTypeUnauthorized, forTestCreateCredentialVote(t, app, votingKey, credential, pollHash, "b"))
+}
+
+func TestCredentialVoteFailOnMoreVotesThanIssuedCredentials(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	cpk := forTestCredentialKey(t)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateCredentialElection(t, app, gov, voterHexs, &cpk.CredentialKey)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a", "b": "b"})
+
+	votingKey, credential := forTestIssueCredential(t, app, gov, voters[0], cpk, electionID)
+	assert.Equal(t, CodeTypeOK, forTestCreateCredentialVote(t, app, votingKey, credential, pollHash, "a"))
+
+	// the gonverment signs a credential outside of the chain
+	otherKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	otherPubB, _ := otherKey.GetPublic().Bytes()
+	blinded, r, err := cpk.Blind(CredentialMessage(electionID, hex.EncodeToString(otherPubB)))
+	assert.Nil(t, err)
+	blindSignature, err := cpk.SignBlinded(blinded)
+	assert.Nil(t, err)
+	otherCredential, err := cpk.Unblind(blindSignature, r)
+	assert.Nil(t, err)
+	assert.Equal(t, CodeTypeUnauthorized, forTestCreateCredentialVote(t, app, otherKey, otherCredential, pollHash, "b"))
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pvq.IssuedCredentials)
+	assert.Equal(t, 1, pvq.CredentialVotes)
+}
+
+func TestCredentialVoteSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	cpk := forTestCredentialKey(t)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateCredentialElection(t, app, gov, voterHexs, &cpk.CredentialKey)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a", "b": "b"})
+
+	votingKey1, credential1 := forTestIssueCredential(t, app, gov, voters[0], cpk, electionID)
+	votingKey2, credential2 := forTestIssueCredential(t, app, gov, voters[1], cpk, electionID)
+	assert.Equal(t, CodeTypeOK, forTestCreateCredentialVote(t, app, votingKey1, credential1, pollHash, "a"))
+	assert.Equal(t, CodeTypeOK, forTestCreateCredentialVote(t, app, votingKey2, credential2, pollHash, "a"))
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pvq.NumberOfVotes)
+	assert.Equal(t, 2, pvq.Choices["a"])
+	assert.Equal(t, 2, pvq.IssuedCredentials)
+	assert.Equal(t, 2, pvq.CredentialVotes)
+
+	// the poll's state does not have the voters' public keys
+	ps, err := app.state.GetPoll(pollHash)
+	assert.Nil(t, err)
+	for _, v := range voterHexs {
+		assert.NotContains(t, ps.VotedAlready, v)
+	}
+}
@@ -0,0 +1,83 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElectionStatusFailOnNonGonverment(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs)
+
+	assert.Equal(t, CodeTypeUnauthorized, forTestChangeElectionStatus(t, app, voters[0], electionID, ELECTION_OPEN))
+}
+
+func TestElectionStatusFailOnSkippingState(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs)
+
+	assert.Equal(t, CodeTypeUnauthorized, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_CLOSED))
+	assert.Equal(t, CodeTypeUnauthorized, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_DRAFT))
+	assert.Equal(t, CodeTypeUnauthorized, forTestChangeElectionStatus(t, app, gov, electionID, ElectionStatus("unknown")))
+}
+
+func TestElectionStatusFailOnPollInDraft(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs)
+
+	pollHash := forTestUploadPoll(t, map[string]string{"a": "a"})
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+}
+
+func TestElectionStatusFailOnVoteWhenClosed(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a"})
+
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_CLOSED))
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+}
+
+func TestElectionStatusFailOnDecryptionWhenArchived(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID, pollHash, trustees, enc, shares := forTestEncryptedPoll(t, app, gov, voterHexs, 1, 1)
+	assert.Equal(t, CodeTypeOK, forTestCreateEncryptedVote(t, app, voters[0], enc, pollHash, []string{"a", "b"}, "a"))
+
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_CLOSED))
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_ARCHIVED))
+	assert.Equal(t, CodeTypeUnauthorized, forTestCreateDecryption(t, app, trustees[0], shares[0], pollHash))
+	assert.Equal(t, CodeTypeUnauthorized, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_OPEN))
+}
+
+func TestElectionStatusSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs)
+	assert.Equal(t, ELECTION_DRAFT, app.queryListElections()[0].Status)
+
+	for _, v := range []ElectionStatus{ELECTION_OPEN, ELECTION_CLOSED, ELECTION_ARCHIVED} {
+		assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, v))
+		assert.Equal(t, v, app.queryListElections()[0].Status)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	crypto "github.com/libp2p/go-libp2p-crypto"
@@ -200,3 +201,19 @@ func TestElectionDeliveryFailOnPuttingTheSameElectionID(t *testing.T) {
 	resp = app.DeliverTx(tx)
 	assert.Equal(t, CodeTypeUnauthorized, resp.Code)
 }
+
+func TestElectionDeliveryFailOnLongName(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+
+	ed := ElectionDeliveryData{}
+	ed.ID = uuid.NewV4().String()
+	ed.From = hex.EncodeToString(pubB)
+	ed.Name = strings.Repeat("n", 201)
+	confs.Conf.GonvermentPublicKeyHex = ed.From
+
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, privk, ELECTION, &ed))
+}
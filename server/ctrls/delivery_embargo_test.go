@@ -0,0 +1,132 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestEmbargoPoll(t *testing.T, app *TVApplication, gov crypto.PrivKey, electionID string) string {
+	pj := PollJson{Description: "mayor", Choices: map[string]string{"a": "alice", "b": "bob"}, Embargo: true}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	return pollHash
+}
+
+func forTestOverrideEmbargo(t *testing.T, app *TVApplication, privk crypto.PrivKey, pollHash, reason string) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+	ed := EmbargoOverrideDeliveryData{From: hex.EncodeToString(pubB), PollHash: pollHash, Reason: reason}
+	return forTestDeliver(t, app, privk, EMBARGO_OVERRIDE, &ed)
+}
+
+func TestEmbargoUntilElectionCloses(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestEmbargoPoll(t, app, gov, electionID)
+	forTestCreateVote(t, app, voters[0], electionID, pollHash, "a")
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.True(t, pvq.Embargoed)
+	assert.Equal(t, 1, pvq.NumberOfVotes)
+	assert.Nil(t, pvq.Choices)
+
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_CLOSED))
+	pvq, err = app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.False(t, pvq.Embargoed)
+	assert.Equal(t, 1, pvq.Choices["a"])
+}
+
+func TestEmbargoUntilPollEnds(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	forTestBlock(app, 1)
+	pj := PollJson{Description: "mayor", Choices: map[string]string{"a": "alice", "b": "bob"}, Embargo: true}
+	pollHash := forTestUploadPollJson(t, pj)
+	pubB, _ := gov.GetPublic().Bytes()
+	pd := PollDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, PollHash: pollHash, EndHeight: 5}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, gov, POLL, &pd))
+	forTestCreateVote(t, app, voters[0], electionID, pollHash, "a")
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.True(t, pvq.Embargoed)
+
+	// the poll is still the latest of the open election, but it has ended
+	forTestBlock(app, 6)
+	pvq, err = app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.False(t, pvq.Embargoed)
+	assert.Equal(t, 1, pvq.Choices["a"])
+}
+
+func TestEmbargoUntilPollIsReplaced(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestEmbargoPoll(t, app, gov, electionID)
+	forTestCreateVote(t, app, voters[0], electionID, pollHash, "a")
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.True(t, pvq.Embargoed)
+
+	newPollHash := forTestUploadPoll(t, map[string]string{"a": "a"})
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, newPollHash))
+	pvq, err = app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.False(t, pvq.Embargoed)
+	assert.Equal(t, 1, pvq.Choices["a"])
+}
+
+func TestEmbargoOverrideFailOnWrongOverrides(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestEmbargoPoll(t, app, gov, electionID)
+
+	// not the gonverment or an auditor
+	assert.Equal(t, CodeTypeUnauthorized, forTestOverrideEmbargo(t, app, voters[0], pollHash, "I want to know"))
+	// without a reason
+	assert.Equal(t, CodeTypeUnauthorized, forTestOverrideEmbargo(t, app, gov, pollHash, ""))
+	// the poll without the embargo
+	otherHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"y": "yes", "n": "no"})
+	assert.Equal(t, CodeTypeUnauthorized, forTestOverrideEmbargo(t, app, gov, otherHash, "The court's order"))
+}
+
+func TestEmbargoOverrideByAuditor(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	auditors, auditorHexs := forTestVoters(t, 1)
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Voters: voterHexs, Auditors: auditorHexs})
+	pollHash := forTestEmbargoPoll(t, app, gov, electionID)
+	forTestCreateVote(t, app, voters[0], electionID, pollHash, "b")
+
+	assert.Equal(t, CodeTypeOK, forTestOverrideEmbargo(t, app, auditors[0], pollHash, "The court's order"))
+	// the embargo is lifted already
+	assert.Equal(t, CodeTypeUnauthorized, forTestOverrideEmbargo(t, app, gov, pollHash, "The court's order"))
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.False(t, pvq.Embargoed)
+	assert.Equal(t, 1, pvq.Choices["b"])
+	assert.Equal(t, 1, len(pvq.EmbargoOverrides))
+	assert.Equal(t, auditorHexs[0], pvq.EmbargoOverrides[0].From)
+	assert.Equal(t, "The court's order", pvq.EmbargoOverrides[0].Reason)
+}
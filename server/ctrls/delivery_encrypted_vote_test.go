@@ -0,0 +1,319 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/mragiadakos/tendervoting/server/confs"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestTrustees(t *testing.T, n, threshold int) ([]crypto.PrivKey, []*ExchangeKey, *ElectionEncryption) {
+	trustees := []crypto.PrivKey{}
+	exchanges := []*ExchangeKey{}
+	trusteeHexs := []string{}
+	exchangeHexs := []string{}
+	for i := 0; i < n; i++ {
+		privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		assert.Nil(t, err)
+		pubB, _ := privk.GetPublic().Bytes()
+		ek, err := NewExchangeKey()
+		assert.Nil(t, err)
+		trustees = append(trustees, privk)
+		exchanges = append(exchanges, ek)
+		trusteeHexs = append(trusteeHexs, hex.EncodeToString(pubB))
+		exchangeHexs = append(exchangeHexs, ek.PublicKey)
+	}
+	enc, err := NewElectionEncryption(trusteeHexs, exchangeHexs, threshold)
+	assert.Nil(t, err)
+	return trustees, exchanges, enc
+}
+
+func forTestDealKey(t *testing.T, app *TVApplication, privk crypto.PrivKey, electionID string) uint32 {
+	es, err := app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	td, err := NewTrusteeDealing(es.Encryption)
+	assert.Nil(t, err)
+	pubB, _ := privk.GetPublic().Bytes()
+	kd := KeyDealingDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, Dealing: *td}
+	return forTestDeliver(t, app, privk, KEY_DEALING, &kd)
+}
+
+// forTestTrusteeShares returns the finished key of the election, and the trustees' shares from the dealings
+func forTestTrusteeShares(t *testing.T, app *TVApplication, electionID string, exchanges []*ExchangeKey) (*ElectionEncryption, []TrusteeShare) {
+	es, err := app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	assert.True(t, es.Encryption.IsKeyFinished())
+	shares := []TrusteeShare{}
+	for i, ek := range exchanges {
+		share, wrong, err := es.Encryption.OpenShares(ek, es.Encryption.Trustees[i].Index)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(wrong))
+		shares = append(shares, *share)
+	}
+	return es.Encryption, shares
+}
+
+// forTestEncryptedPoll creates the encrypted election, the trustees deal the key,
+// and the first poll after the complaints' period finishes it
+func forTestEncryptedPoll(t *testing.T, app *TVApplication, gov crypto.PrivKey, voters []string, n, threshold int) (string, string, []crypto.PrivKey, *ElectionEncryption, []TrusteeShare) {
+	trustees, exchanges, enc := forTestTrustees(t, n, threshold)
+	electionID := forTestCreateEncryptedElection(t, app, gov, voters, enc)
+	for _, v := range trustees {
+		assert.Equal(t, CodeTypeOK, forTestDealKey(t, app, v, electionID))
+	}
+	forTestBlock(app, app.state.Height+KeyComplaintBlocks+1)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a", "b": "b"})
+	enc, shares := forTestTrusteeShares(t, app, electionID, exchanges)
+	return electionID, pollHash, trustees, enc, shares
+}
+
+func TestEncryptedElectionFailOnPublicKeyFromGonverment(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, _, enc := forTestTrustees(t, 3, 2)
+	// the gonverment can not give the election's key, because it would know its secret
+	enc.PublicKey = enc.Trustees[0].ExchangeKey
+
+	pubB, _ := privk.GetPublic().Bytes()
+	ed := ElectionDeliveryData{}
+	ed.ID = uuid.NewV4().String()
+	ed.From = hex.EncodeToString(pubB)
+	ed.Encryption = enc
+	confs.Conf.GonvermentPublicKeyHex = ed.From
+
+	b, _ := json.Marshal(ed)
+	sign, err := privk.Sign(b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = ELECTION
+	tvd.Signature = sign
+	tvd.Data = &ed
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	assert.Equal(t, CodeTypeUnauthorized, resp.Code)
+}
+
+func TestEncryptedVoteFailOnPlainVote(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	pubHex := hex.EncodeToString(pubB)
+	_, pollHash, _, _, _ := forTestEncryptedPoll(t, app, privk, []string{pubHex}, 1, 1)
+
+	vd := VoteDeliveryData{}
+	vd.From = pubHex
+	vd.PollHash = pollHash
+	vd.Choice = "a"
+	b, _ := json.Marshal(vd)
+	sign, err := privk.Sign(b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = VOTE
+	tvd.Signature = sign
+	tvd.Data = &vd
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	assert.Equal(t, CodeTypeUnauthorized, resp.Code)
+}
+
+func TestEncryptedVoteFailOnNotEncryptedPoll(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	// the key is from another chain's encrypted election
+	_, _, _, enc, _ := forTestEncryptedPoll(t, NewTVApplication(), privk, []string{}, 1, 1)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	electionID := forTestCreateElection(t, app, privk, []string{hex.EncodeToString(pubB)})
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	code := forTestCreateEncryptedVote(t, app, privk, enc, pollHash, []string{"a", "b"}, "a")
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestEncryptedVoteFailOnMissingChoice(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	_, pollHash, _, enc, _ := forTestEncryptedPoll(t, app, privk, []string{hex.EncodeToString(pubB)}, 1, 1)
+
+	code := forTestCreateEncryptedVote(t, app, privk, enc, pollHash, []string{"a"}, "a")
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestDecryptionFailOnNotTrustee(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	electionID, pollHash, _, _, shares := forTestEncryptedPoll(t, app, privk, []string{hex.EncodeToString(pubB)}, 1, 1)
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, privk, electionID, ELECTION_CLOSED))
+
+	code := forTestCreateDecryption(t, app, privk, shares[0], pollHash)
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestDecryptionFailOnWrongShare(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	electionID, pollHash, trustees, _, shares := forTestEncryptedPoll(t, app, privk, []string{hex.EncodeToString(pubB)}, 2, 2)
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, privk, electionID, ELECTION_CLOSED))
+
+	// the first trustee uses the share of the second
+	code := forTestCreateDecryption(t, app, trustees[0], shares[1], pollHash)
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestDecryptionFailOnOpenPoll(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	electionID, pollHash, trustees, enc, shares := forTestEncryptedPoll(t, app, privk, []string{hex.EncodeToString(pubB)}, 2, 2)
+
+	// a trustee can not close the poll with its decryption
+	code := forTestCreateDecryption(t, app, trustees[0], shares[0], pollHash)
+	assert.Equal(t, CodeTypeUnauthorized, code)
+	code = forTestCreateEncryptedVote(t, app, privk, enc, pollHash, []string{"a", "b"}, "a")
+	assert.Equal(t, CodeTypeOK, code)
+
+	// the next poll closes the poll, so it can be decrypted
+	forTestCreatePoll(t, app, privk, electionID, map[string]string{"y": "yes", "n": "no"})
+	code = forTestCreateDecryption(t, app, trustees[0], shares[0], pollHash)
+	assert.Equal(t, CodeTypeOK, code)
+}
+
+func TestEncryptedVoteSuccessfulWithThresholdDecryption(t *testing.T) {
+	app := NewTVApplication()
+	gonPrivk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	voters := []crypto.PrivKey{}
+	voterHexs := []string{}
+	for i := 0; i < 3; i++ {
+		privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		assert.Nil(t, err)
+		pubB, _ := privk.GetPublic().Bytes()
+		voters = append(voters, privk)
+		voterHexs = append(voterHexs, hex.EncodeToString(pubB))
+	}
+	electionID, pollHash, trustees, enc, shares := forTestEncryptedPoll(t, app, gonPrivk, voterHexs, 3, 2)
+
+	choices := []string{"a", "b"}
+	assert.Equal(t, CodeTypeOK, forTestCreateEncryptedVote(t, app, voters[0], enc, pollHash, choices, "a"))
+	assert.Equal(t, CodeTypeOK, forTestCreateEncryptedVote(t, app, voters[1], enc, pollHash, choices, "b"))
+	assert.Equal(t, CodeTypeOK, forTestCreateEncryptedVote(t, app, voters[2], enc, pollHash, choices, "a"))
+	// voting second time
+	assert.Equal(t, CodeTypeUnauthorized, forTestCreateEncryptedVote(t, app, voters[2], enc, pollHash, choices, "b"))
+
+	// the third and the first trustee decrypt after the election is closed
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gonPrivk, electionID, ELECTION_CLOSED))
+	assert.Equal(t, CodeTypeOK, forTestCreateDecryption(t, app, trustees[2], shares[2], pollHash))
+	ps, _ := app.state.GetPoll(pollHash)
+	assert.False(t, ps.Tallied)
+	assert.Equal(t, 0, ps.Choices["a"])
+
+	assert.Equal(t, CodeTypeOK, forTestCreateDecryption(t, app, trustees[0], shares[0], pollHash))
+	ps, _ = app.state.GetPoll(pollHash)
+	assert.True(t, ps.Tallied)
+	assert.Equal(t, 2, ps.Choices["a"])
+	assert.Equal(t, 1, ps.Choices["b"])
+
+	// the tally is released, so the rest of the trustees can not decrypt
+	assert.Equal(t, CodeTypeUnauthorized, forTestCreateDecryption(t, app, trustees[1], shares[1], pollHash))
+}
+
+func TestEncryptedVoteFailOnCiphertextThatIsNotZeroOrOne(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	pubHex := hex.EncodeToString(pubB)
+	_, pollHash, _, enc, _ := forTestEncryptedPoll(t, app, privk, []string{pubHex}, 1, 1)
+
+	vd := EncryptedVoteDeliveryData{}
+	vd.From = pubHex
+	vd.PollHash = pollHash
+	ballot, err := enc.EncryptBallot(BallotContext(vd.From, pollHash), []string{"a", "b"}, "a")
+	assert.Nil(t, err)
+	vd.EncryptedBallot = *ballot
+	// the voter tries to add 100 votes for the choice
+	vd.Ballot["a"], _, err = enc.Encrypt(100)
+	assert.Nil(t, err)
+
+	code := forTestDeliverEncryptedVote(t, app, privk, vd)
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestEncryptedVoteFailOnTwoSelections(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	pubHex := hex.EncodeToString(pubB)
+	_, pollHash, _, enc, _ := forTestEncryptedPoll(t, app, privk, []string{pubHex}, 1, 1)
+
+	// each ciphertext encrypts 1 with a correct proof, but the sum is 2
+	context := BallotContext(pubHex, pollHash)
+	ballotA, err := enc.EncryptBallot(context, []string{"a", "b"}, "a")
+	assert.Nil(t, err)
+	ballotB, err := enc.EncryptBallot(context, []string{"a", "b"}, "b")
+	assert.Nil(t, err)
+	vd := EncryptedVoteDeliveryData{}
+	vd.From = pubHex
+	vd.PollHash = pollHash
+	vd.EncryptedBallot = *ballotA
+	vd.Ballot["b"] = ballotB.Ballot["b"]
+	vd.Proofs["b"] = ballotB.Proofs["b"]
+
+	code := forTestDeliverEncryptedVote(t, app, privk, vd)
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestEncryptedVoteFailOnCopiedBallot(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	otherVoter, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	otherPubB, _ := otherVoter.GetPublic().Bytes()
+	pubHex := hex.EncodeToString(pubB)
+	otherPubHex := hex.EncodeToString(otherPubB)
+	_, pollHash, _, enc, _ := forTestEncryptedPoll(t, app, privk, []string{pubHex, otherPubHex}, 1, 1)
+
+	vd := EncryptedVoteDeliveryData{}
+	vd.From = pubHex
+	vd.PollHash = pollHash
+	ballot, err := enc.EncryptBallot(BallotContext(vd.From, pollHash), []string{"a", "b"}, "a")
+	assert.Nil(t, err)
+	vd.EncryptedBallot = *ballot
+	assert.Equal(t, CodeTypeOK, forTestDeliverEncryptedVote(t, app, privk, vd))
+
+	// the other voter submits the same ballot
+	vd.From = otherPubHex
+	code := forTestDeliverEncryptedVote(t, app, otherVoter, vd)
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
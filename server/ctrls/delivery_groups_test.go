@@ -0,0 +1,127 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/mragiadakos/tendervoting/server/confs"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestCreateGroupsElection(t *testing.T, app *TVApplication, privk crypto.PrivKey, voters []string, groups map[string][]string) string {
+	return forTestDeliverElection(t, app, privk, ElectionDeliveryData{Voters: voters, Groups: groups})
+}
+
+func forTestGroupsPoll(t *testing.T, app *TVApplication, gov crypto.PrivKey, electionID string, groups []string) string {
+	pj := PollJson{Description: "the new school", Choices: map[string]string{"y": "yes", "n": "no"}, Groups: groups}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	return pollHash
+}
+
+func TestGroupsElectionFailOnWrongGroups(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 2)
+	_, otherHexs := forTestVoters(t, 1)
+
+	wrongs := []ElectionDeliveryData{
+		// a voter that is not in the election
+		{Voters: voterHexs, Groups: map[string][]string{otherHexs[0]: {"north"}}},
+		// empty group
+		{Voters: voterHexs, Groups: map[string][]string{voterHexs[0]: {""}}},
+		// the same group twice
+		{Voters: voterHexs, Groups: map[string][]string{voterHexs[0]: {"north", "north"}}},
+		// anonymous election
+		{Voters: voterHexs, Groups: map[string][]string{voterHexs[0]: {"north"}}, Anonymous: true},
+	}
+	pubB, _ := gov.GetPublic().Bytes()
+	confs.Conf.GonvermentPublicKeyHex = hex.EncodeToString(pubB)
+	for _, ed := range wrongs {
+		ed.ID = uuid.NewV4().String()
+		ed.From = confs.Conf.GonvermentPublicKeyHex
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, gov, ELECTION, &ed))
+	}
+}
+
+func TestGroupsPollFailOnGroupThatDoesNotExist(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateGroupsElection(t, app, gov, voterHexs, map[string][]string{voterHexs[0]: {"north"}})
+
+	pj := PollJson{Description: "the new school", Choices: map[string]string{"y": "yes"}, Groups: []string{"south"}}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+}
+
+func TestGroupsVoteFailOnVoterOutsideTheGroups(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	groups := map[string][]string{voterHexs[0]: {"north"}, voterHexs[1]: {"south"}}
+	electionID := forTestCreateGroupsElection(t, app, gov, voterHexs, groups)
+	pollHash := forTestGroupsPoll(t, app, gov, electionID, []string{"north"})
+
+	// the voter of another group and the voter without group
+	for _, i := range []int{1, 2} {
+		vd := VoteDeliveryData{From: voterHexs[i], PollHash: pollHash, Choice: "y"}
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[i], VOTE, &vd))
+	}
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "y"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+}
+
+func TestGroupsResultsSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 4)
+	groups := map[string][]string{
+		voterHexs[0]: {"north", "teachers"},
+		voterHexs[1]: {"north"},
+		voterHexs[2]: {"south", "teachers"},
+	}
+	electionID := forTestCreateGroupsElection(t, app, gov, voterHexs, groups)
+	pollHash := forTestGroupsPoll(t, app, gov, electionID, nil)
+
+	choices := []string{"y", "n", "y", "n"}
+	for i, c := range choices {
+		vd := VoteDeliveryData{From: voterHexs[i], PollHash: pollHash, Choice: c}
+		assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[i], VOTE, &vd))
+	}
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"y": 2, "n": 2}, pvq.Choices)
+	assert.Equal(t, map[string]int{"north": 2, "south": 1, "teachers": 2}, pvq.GroupVotes)
+	assert.Equal(t, map[string]int{"y": 1, "n": 1}, pvq.GroupChoices["north"])
+	assert.Equal(t, map[string]int{"y": 2}, pvq.GroupChoices["teachers"])
+}
+
+func TestGroupsAddVotersSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Voters: voterHexs[:1], Draft: true,
+		Groups: map[string][]string{voterHexs[0]: {"north"}}})
+
+	pubB, _ := gov.GetPublic().Bytes()
+	vd := VotersDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, Voters: voterHexs[1:],
+		Groups: map[string][]string{voterHexs[1]: {"south"}}}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, gov, ADD_VOTERS, &vd))
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_OPEN))
+
+	pollHash := forTestGroupsPoll(t, app, gov, electionID, []string{"south"})
+	vote := VoteDeliveryData{From: voterHexs[1], PollHash: pollHash, Choice: "n"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[1], VOTE, &vote))
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"south": 1}, pvq.GroupVotes)
+}
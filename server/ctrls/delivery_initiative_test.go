@@ -0,0 +1,96 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestInitiativePollHash(t *testing.T) string {
+	pj := PollJson{Description: "the new park", Choices: map[string]string{"y": "yes", "n": "no"}}
+	return forTestUploadPollJson(t, pj)
+}
+
+func TestInitiativeFailOnElectionWithoutShare(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestInitiativePollHash(t)
+
+	id := InitiativeDeliveryData{From: voterHexs[0], ElectionID: electionID, PollHash: pollHash}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], INITIATIVE, &id))
+}
+
+func TestInitiativeFailOnNotVoter(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 2)
+	others, otherHexs := forTestVoters(t, 1)
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Voters: voterHexs, InitiativeShare: 50})
+	pollHash := forTestInitiativePollHash(t)
+
+	id := InitiativeDeliveryData{From: otherHexs[0], ElectionID: electionID, PollHash: pollHash}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, others[0], INITIATIVE, &id))
+}
+
+func TestInitiativeFailOnSupportTwice(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 4)
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Voters: voterHexs, InitiativeShare: 75})
+	pollHash := forTestInitiativePollHash(t)
+
+	id := InitiativeDeliveryData{From: voterHexs[0], ElectionID: electionID, PollHash: pollHash}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], INITIATIVE, &id))
+	// the proposer supports the initiative already
+	sd := SupportDeliveryData{From: voterHexs[0], PollHash: pollHash}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], SUPPORT, &sd))
+	// the same poll can not be proposed twice
+	id = InitiativeDeliveryData{From: voterHexs[1], ElectionID: electionID, PollHash: pollHash}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[1], INITIATIVE, &id))
+}
+
+func TestInitiativeSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 4)
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Voters: voterHexs, InitiativeShare: 75})
+	pollHash := forTestInitiativePollHash(t)
+
+	id := InitiativeDeliveryData{From: voterHexs[0], ElectionID: electionID, PollHash: pollHash}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], INITIATIVE, &id))
+	sd := SupportDeliveryData{From: voterHexs[1], PollHash: pollHash}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[1], SUPPORT, &sd))
+
+	iq, err := app.queryInitiative(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, iq.Supporters)
+	assert.Equal(t, 3, iq.Needed)
+	assert.False(t, iq.Created)
+	assert.False(t, app.state.HasPoll(pollHash))
+
+	// the voter can not vote before the initiative becomes a poll
+	vd := VoteDeliveryData{From: voterHexs[3], PollHash: pollHash, Choice: "y"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[3], VOTE, &vd))
+
+	sd = SupportDeliveryData{From: voterHexs[2], PollHash: pollHash}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[2], SUPPORT, &sd))
+	assert.True(t, app.state.HasPoll(pollHash))
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[3], VOTE, &vd))
+
+	// the initiative is a poll, so it does not need more support
+	sd = SupportDeliveryData{From: voterHexs[3], PollHash: pollHash}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[3], SUPPORT, &sd))
+
+	list := app.queryListInitiatives()
+	assert.Equal(t, 1, len(list))
+	assert.True(t, list[0].Created)
+	assert.Equal(t, voterHexs[0], list[0].Proposer)
+}
@@ -0,0 +1,136 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/mragiadakos/tendervoting/server/confs"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestCommitInvitation(t *testing.T, app *TVApplication, privk crypto.PrivKey, pubHex, electionID, code string) uint32 {
+	cd := CommitInvitationDeliveryData{From: pubHex, ElectionID: electionID, Commitment: InvitationCommitmentHash(code, pubHex)}
+	return forTestDeliver(t, app, privk, COMMIT_INVITATION, &cd)
+}
+
+func forTestRedeemInvitation(t *testing.T, app *TVApplication, privk crypto.PrivKey, pubHex, electionID, code string) uint32 {
+	rd := RedeemInvitationDeliveryData{From: pubHex, ElectionID: electionID, Code: code}
+	return forTestDeliver(t, app, privk, REDEEM_INVITATION, &rd)
+}
+
+// forTestCommitAndRedeemInvitation commits to the code, and redeems it in the next block
+func forTestCommitAndRedeemInvitation(t *testing.T, app *TVApplication, privk crypto.PrivKey, pubHex, electionID, code string) uint32 {
+	resp := forTestCommitInvitation(t, app, privk, pubHex, electionID, code)
+	if resp != CodeTypeOK {
+		return resp
+	}
+	forTestBlock(app, app.state.Height+1)
+	return forTestRedeemInvitation(t, app, privk, pubHex, electionID, code)
+}
+
+func TestInvitationElectionFailOnWrongHashes(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	pubB, _ := gov.GetPublic().Bytes()
+
+	wrongs := [][]string{
+		// not a hash
+		{"abcd"},
+		// the same hash twice
+		{InvitationHash("one"), InvitationHash("one")},
+		// the hash is not in lowercase, so it could not be redeemed
+		{strings.ToUpper(InvitationHash("one"))},
+	}
+	for _, invitations := range wrongs {
+		ed := ElectionDeliveryData{ID: "election", From: hex.EncodeToString(pubB), Invitations: invitations}
+		confs.Conf.GonvermentPublicKeyHex = ed.From
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, gov, ELECTION, &ed))
+	}
+}
+
+func TestInvitationFailOnOpenElection(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	code := "K7PQ-2M9X-4TZA"
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Draft: true, Invitations: []string{InvitationHash(code)}})
+
+	assert.Equal(t, CodeTypeOK, forTestCommitInvitation(t, app, voters[0], voterHexs[0], electionID, code))
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_OPEN))
+	forTestBlock(app, 1)
+	assert.Equal(t, CodeTypeUnauthorized, forTestRedeemInvitation(t, app, voters[0], voterHexs[0], electionID, code))
+	assert.Equal(t, CodeTypeUnauthorized, forTestCommitInvitation(t, app, voters[0], voterHexs[0], electionID, code))
+}
+
+func TestInvitationFailWithoutCommitment(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	code := "K7PQ-2M9X-4TZA"
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Draft: true, Invitations: []string{InvitationHash(code)}})
+
+	assert.Equal(t, CodeTypeUnauthorized, forTestRedeemInvitation(t, app, voters[0], voterHexs[0], electionID, code))
+	// the commitment is for another code
+	assert.Equal(t, CodeTypeOK, forTestCommitInvitation(t, app, voters[0], voterHexs[0], electionID, "AAAA-BBBB-CCCC"))
+	forTestBlock(app, 1)
+	assert.Equal(t, CodeTypeUnauthorized, forTestRedeemInvitation(t, app, voters[0], voterHexs[0], electionID, code))
+}
+
+func TestInvitationFailOnCodeFromTheMempool(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	code := "K7PQ-2M9X-4TZA"
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Draft: true, Invitations: []string{InvitationHash(code)}})
+
+	forTestBlock(app, 1)
+	assert.Equal(t, CodeTypeOK, forTestCommitInvitation(t, app, voters[0], voterHexs[0], electionID, code))
+	// the other key sees the code of the voter's redemption, and commits to it with its own key
+	forTestBlock(app, 2)
+	assert.Equal(t, CodeTypeOK, forTestCommitInvitation(t, app, voters[1], voterHexs[1], electionID, code))
+	assert.Equal(t, CodeTypeUnauthorized, forTestRedeemInvitation(t, app, voters[1], voterHexs[1], electionID, code))
+	assert.Equal(t, CodeTypeOK, forTestRedeemInvitation(t, app, voters[0], voterHexs[0], electionID, code))
+
+	es, err := app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{voterHexs[0]}, es.Voters)
+}
+
+func TestInvitationSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	codes := []string{"K7PQ-2M9X-4TZA", "W3RV-8NBD-6HCE"}
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Draft: true,
+		Invitations: []string{InvitationHash(codes[0]), InvitationHash(codes[1])}})
+
+	// a code that is not in the election
+	assert.Equal(t, CodeTypeUnauthorized, forTestCommitAndRedeemInvitation(t, app, voters[0], voterHexs[0], electionID, "AAAA-BBBB-CCCC"))
+	assert.Equal(t, CodeTypeOK, forTestCommitAndRedeemInvitation(t, app, voters[0], voterHexs[0], electionID, codes[0]))
+	// the code is spent
+	assert.Equal(t, CodeTypeUnauthorized, forTestCommitAndRedeemInvitation(t, app, voters[1], voterHexs[1], electionID, codes[0]))
+	// the voter is in the election already
+	assert.Equal(t, CodeTypeUnauthorized, forTestCommitAndRedeemInvitation(t, app, voters[0], voterHexs[0], electionID, codes[1]))
+
+	edq, err := app.queryElectionDetails(electionID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, edq.NumberOfVoters)
+	assert.Equal(t, 2, edq.Invitations)
+	assert.Equal(t, 1, edq.PendingInvitations)
+
+	// the voter of the invitation votes with the key
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_OPEN))
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice", "b": "bob"})
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	vd = VoteDeliveryData{From: voterHexs[2], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[2], VOTE, &vd))
+}
@@ -0,0 +1,160 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestKeyComplaint(t *testing.T, app *TVApplication, privk crypto.PrivKey, ek *ExchangeKey, electionID string, dealer crypto.PrivKey) uint32 {
+	es, err := app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	pubB, _ := privk.GetPublic().Bytes()
+	dealerB, _ := dealer.GetPublic().Bytes()
+	complainer, _ := es.Encryption.GetTrustee(hex.EncodeToString(pubB))
+	d, _ := es.Encryption.GetTrustee(hex.EncodeToString(dealerB))
+	key, proof, err := ek.ProveShareKey(d.Dealing.Shares[complainer.Index])
+	assert.Nil(t, err)
+	kc := KeyComplaintDeliveryData{From: complainer.PublicKey, ElectionID: electionID, Dealer: d.PublicKey, Key: key, Proof: proof}
+	return forTestDeliver(t, app, privk, KEY_COMPLAINT, &kc)
+}
+
+func TestKeyDealingFailOnNotTrustee(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	_, _, enc := forTestTrustees(t, 2, 2)
+	electionID := forTestCreateEncryptedElection(t, app, gov, voterHexs, enc)
+
+	assert.Equal(t, CodeTypeUnauthorized, forTestDealKey(t, app, gov, electionID))
+}
+
+func TestKeyDealingFailOnWrongProof(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	trustees, _, enc := forTestTrustees(t, 2, 2)
+	electionID := forTestCreateEncryptedElection(t, app, gov, voterHexs, enc)
+
+	// the trustee's key is chosen from another dealing, so it does not know its secret
+	td, err := NewTrusteeDealing(enc)
+	assert.Nil(t, err)
+	other, err := NewTrusteeDealing(enc)
+	assert.Nil(t, err)
+	td.Commitments[0] = other.Commitments[0]
+	pubB, _ := trustees[0].GetPublic().Bytes()
+	kd := KeyDealingDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, Dealing: *td}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, trustees[0], KEY_DEALING, &kd))
+}
+
+func TestKeyGenerationFailOnPollBeforeDealings(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	trustees, _, enc := forTestTrustees(t, 2, 2)
+	electionID := forTestCreateEncryptedElection(t, app, gov, voterHexs, enc)
+
+	assert.Equal(t, CodeTypeOK, forTestDealKey(t, app, trustees[0], electionID))
+	// the second trustee has not dealt
+	pollHash := forTestUploadPoll(t, map[string]string{"a": "a", "b": "b"})
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	// the trustee can deal only once
+	assert.Equal(t, CodeTypeUnauthorized, forTestDealKey(t, app, trustees[0], electionID))
+}
+
+func TestKeyComplaintDisqualifiesWrongDealing(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	trustees, exchanges, enc := forTestTrustees(t, 3, 2)
+	electionID := forTestCreateEncryptedElection(t, app, gov, voterHexs, enc)
+
+	// the first trustee sends a wrong share to the second
+	td, err := NewTrusteeDealing(enc)
+	assert.Nil(t, err)
+	td.Shares[2] = td.Shares[3]
+	pubB, _ := trustees[0].GetPublic().Bytes()
+	kd := KeyDealingDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, Dealing: *td}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, trustees[0], KEY_DEALING, &kd))
+	assert.Equal(t, CodeTypeOK, forTestDealKey(t, app, trustees[1], electionID))
+	assert.Equal(t, CodeTypeOK, forTestDealKey(t, app, trustees[2], electionID))
+
+	es, err := app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	_, wrong, err := es.Encryption.OpenShares(exchanges[1], 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{hex.EncodeToString(pubB)}, wrong)
+
+	// the complaint against a correct dealing is rejected
+	assert.Equal(t, CodeTypeUnauthorized, forTestKeyComplaint(t, app, trustees[1], exchanges[1], electionID, trustees[2]))
+	assert.Equal(t, CodeTypeOK, forTestKeyComplaint(t, app, trustees[1], exchanges[1], electionID, trustees[0]))
+	es, err = app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	assert.True(t, es.Encryption.Trustees[0].Dealing.Disqualified)
+
+	// the poll can not finish the key during the complaints' period
+	pollHash := forTestUploadPoll(t, map[string]string{"a": "a", "b": "b"})
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+
+	// the key is only from the qualified dealings, and the disqualified trustee still has a share
+	forTestBlock(app, KeyComplaintBlocks+1)
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	enc, shares := forTestTrusteeShares(t, app, electionID, exchanges)
+	h := groupMul(mustHexToBig(enc.Trustees[1].Dealing.Commitments[0]), mustHexToBig(enc.Trustees[2].Dealing.Commitments[0]))
+	assert.Equal(t, bigToHex(h), enc.PublicKey)
+	for i, v := range shares {
+		assert.Nil(t, VerifyTrusteeShare(enc.Trustees[i], v))
+	}
+}
+
+func TestKeyComplaintFailAfterDeadline(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	trustees, exchanges, enc := forTestTrustees(t, 2, 2)
+	electionID := forTestCreateEncryptedElection(t, app, gov, voterHexs, enc)
+
+	forTestBlock(app, 5)
+	td, err := NewTrusteeDealing(enc)
+	assert.Nil(t, err)
+	td.Shares[2] = td.Shares[1]
+	pubB, _ := trustees[0].GetPublic().Bytes()
+	kd := KeyDealingDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, Dealing: *td}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, trustees[0], KEY_DEALING, &kd))
+	assert.Equal(t, CodeTypeOK, forTestDealKey(t, app, trustees[1], electionID))
+
+	es, err := app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5+KeyComplaintBlocks), es.Encryption.ComplaintDeadline())
+
+	forTestBlock(app, 6+KeyComplaintBlocks)
+	assert.Equal(t, CodeTypeUnauthorized, forTestKeyComplaint(t, app, trustees[1], exchanges[1], electionID, trustees[0]))
+}
+
+func TestKeyGenerationSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID, _, trustees, enc, shares := forTestEncryptedPoll(t, app, gov, voterHexs, 3, 2)
+
+	h := mustHexToBig("1")
+	for _, v := range enc.Trustees {
+		h = groupMul(h, mustHexToBig(v.Dealing.Commitments[0]))
+	}
+	assert.Equal(t, bigToHex(h), enc.PublicKey)
+	for i, v := range shares {
+		assert.Nil(t, VerifyTrusteeShare(enc.Trustees[i], v))
+	}
+
+	// the first poll finished the key, so the trustees can not deal or complain anymore
+	assert.Equal(t, CodeTypeUnauthorized, forTestDealKey(t, app, trustees[0], electionID))
+}
@@ -1,11 +1,14 @@
 package ctrls
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"os"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/satori/go.uuid"
 
@@ -21,6 +24,7 @@ const (
 	CodeTypeBadNonce      uint32 = 2
 	CodeTypeUnauthorized  uint32 = 3
 	CodeTypeServerError   uint32 = 4
+	CodeTypePaused        uint32 = 5
 )
 
 type DeliveryType string
@@ -30,11 +34,37 @@ type DeliveryDataInterface interface {
 }
 
 const (
-	ELECTION = DeliveryType("election")
-	POLL     = DeliveryType("poll")
-	VOTE     = DeliveryType("vote")
+	ELECTION           = DeliveryType("election")
+	POLL               = DeliveryType("poll")
+	VOTE               = DeliveryType("vote")
+	ENCRYPTED_VOTE     = DeliveryType("encrypted_vote")
+	DECRYPTION         = DeliveryType("decryption")
+	ANONYMOUS_VOTE     = DeliveryType("anonymous_vote")
+	CREDENTIAL_REQUEST = DeliveryType("credential_request")
+	CREDENTIAL         = DeliveryType("credential")
+	CREDENTIAL_VOTE    = DeliveryType("credential_vote")
+	ADD_VOTERS         = DeliveryType("add_voters")
+	REMOVE_VOTERS      = DeliveryType("remove_voters")
+	ELECTION_STATUS    = DeliveryType("election_status")
+	MERGE_WRITE_INS    = DeliveryType("merge_write_ins")
+	PAUSE              = DeliveryType("pause")
+	RESUME             = DeliveryType("resume")
+	CANCEL_POLL        = DeliveryType("cancel_poll")
+	INITIATIVE         = DeliveryType("initiative")
+	SUPPORT            = DeliveryType("support")
+	REGISTRATION       = DeliveryType("registration")
+	APPROVE            = DeliveryType("approve")
+	REJECT             = DeliveryType("reject")
+	REPLACE_KEY        = DeliveryType("replace_key")
+	REDEEM_INVITATION  = DeliveryType("redeem_invitation")
+	COMMIT_INVITATION  = DeliveryType("commit_invitation")
+	EMBARGO_OVERRIDE   = DeliveryType("embargo_override")
+	KEY_DEALING        = DeliveryType("key_dealing")
+	KEY_COMPLAINT      = DeliveryType("key_complaint")
 )
 
+var errDeliveryType = errors.New("The type for the delivery can only be 'election', 'poll', 'vote', 'encrypted_vote', 'decryption', 'anonymous_vote', 'credential_request', 'credential', 'credential_vote', 'add_voters', 'remove_voters', 'election_status', 'merge_write_ins', 'pause', 'resume', 'cancel_poll', 'initiative', 'support', 'registration', 'approve', 'reject', 'replace_key', 'redeem_invitation', 'commit_invitation', 'embargo_override', 'key_dealing' or 'key_complaint'.")
+
 type TVDelivery struct {
 	Signature []byte
 	Type      DeliveryType
@@ -53,8 +83,70 @@ func (v *TVDelivery) GetFrom() (string, error) {
 	case VOTE:
 		d := v.GetVoteDeliveryData()
 		pubHex = d.From
+	case ENCRYPTED_VOTE:
+		d := v.GetEncryptedVoteDeliveryData()
+		pubHex = d.From
+	case DECRYPTION:
+		d := v.GetDecryptionDeliveryData()
+		pubHex = d.From
+	case ANONYMOUS_VOTE:
+		return "", errors.New("The anonymous vote is signed by the ring of the election's voters.")
+	case CREDENTIAL_REQUEST:
+		d := v.GetCredentialRequestDeliveryData()
+		pubHex = d.From
+	case CREDENTIAL:
+		d := v.GetCredentialDeliveryData()
+		pubHex = d.From
+	case CREDENTIAL_VOTE:
+		d := v.GetCredentialVoteDeliveryData()
+		pubHex = d.From
+	case ADD_VOTERS, REMOVE_VOTERS:
+		d := v.GetVotersDeliveryData()
+		pubHex = d.From
+	case ELECTION_STATUS:
+		d := v.GetElectionStatusDeliveryData()
+		pubHex = d.From
+	case MERGE_WRITE_INS:
+		d := v.GetMergeWriteInsDeliveryData()
+		pubHex = d.From
+	case PAUSE, RESUME:
+		d := v.GetPauseDeliveryData()
+		pubHex = d.From
+	case CANCEL_POLL:
+		d := v.GetCancelPollDeliveryData()
+		pubHex = d.From
+	case INITIATIVE:
+		d := v.GetInitiativeDeliveryData()
+		pubHex = d.From
+	case SUPPORT:
+		d := v.GetSupportDeliveryData()
+		pubHex = d.From
+	case REGISTRATION:
+		d := v.GetRegistrationDeliveryData()
+		pubHex = d.From
+	case APPROVE, REJECT:
+		d := v.GetRegistrationDecisionDeliveryData()
+		pubHex = d.From
+	case REPLACE_KEY:
+		d := v.GetReplaceKeyDeliveryData()
+		pubHex = d.From
+	case REDEEM_INVITATION:
+		d := v.GetRedeemInvitationDeliveryData()
+		pubHex = d.From
+	case COMMIT_INVITATION:
+		d := v.GetCommitInvitationDeliveryData()
+		pubHex = d.From
+	case EMBARGO_OVERRIDE:
+		d := v.GetEmbargoOverrideDeliveryData()
+		pubHex = d.From
+	case KEY_DEALING:
+		d := v.GetKeyDealingDeliveryData()
+		pubHex = d.From
+	case KEY_COMPLAINT:
+		d := v.GetKeyComplaintDeliveryData()
+		pubHex = d.From
 	default:
-		return "", errors.New("The type for the delivery can only be 'election', 'poll' or 'vote'.")
+		return "", errDeliveryType
 	}
 	return pubHex, nil
 }
@@ -80,6 +172,153 @@ func (v *TVDelivery) GetVoteDeliveryData() VoteDeliveryData {
 	return d
 }
 
+func (v *TVDelivery) GetEncryptedVoteDeliveryData() EncryptedVoteDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := EncryptedVoteDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetDecryptionDeliveryData() DecryptionDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := DecryptionDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetAnonymousVoteDeliveryData() AnonymousVoteDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := AnonymousVoteDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetCredentialRequestDeliveryData() CredentialRequestDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := CredentialRequestDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetCredentialDeliveryData() CredentialDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := CredentialDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetCredentialVoteDeliveryData() CredentialVoteDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := CredentialVoteDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetVotersDeliveryData() VotersDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := VotersDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetElectionStatusDeliveryData() ElectionStatusDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := ElectionStatusDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetMergeWriteInsDeliveryData() MergeWriteInsDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := MergeWriteInsDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetPauseDeliveryData() PauseDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := PauseDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetCancelPollDeliveryData() CancelPollDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := CancelPollDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetInitiativeDeliveryData() InitiativeDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := InitiativeDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetSupportDeliveryData() SupportDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := SupportDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetRegistrationDeliveryData() RegistrationDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := RegistrationDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetRegistrationDecisionDeliveryData() RegistrationDecisionDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := RegistrationDecisionDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetReplaceKeyDeliveryData() ReplaceKeyDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := ReplaceKeyDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetRedeemInvitationDeliveryData() RedeemInvitationDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := RedeemInvitationDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetCommitInvitationDeliveryData() CommitInvitationDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := CommitInvitationDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetEmbargoOverrideDeliveryData() EmbargoOverrideDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := EmbargoOverrideDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetKeyDealingDeliveryData() KeyDealingDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := KeyDealingDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
+func (v *TVDelivery) GetKeyComplaintDeliveryData() KeyComplaintDeliveryData {
+	b, _ := json.Marshal(v.Data)
+	d := KeyComplaintDeliveryData{}
+	json.Unmarshal(b, &d)
+	return d
+}
+
 func (v *TVDelivery) GetDataInStructureOrder() ([]byte, error) {
 	b, _ := json.Marshal(v.Data)
 	out := []byte("")
@@ -96,8 +335,92 @@ func (v *TVDelivery) GetDataInStructureOrder() ([]byte, error) {
 		d := VoteDeliveryData{}
 		json.Unmarshal(b, &d)
 		out, _ = json.Marshal(d)
+	case ENCRYPTED_VOTE:
+		d := EncryptedVoteDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case DECRYPTION:
+		d := DecryptionDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case ANONYMOUS_VOTE:
+		d := AnonymousVoteDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case CREDENTIAL_REQUEST:
+		d := CredentialRequestDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case CREDENTIAL:
+		d := CredentialDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case CREDENTIAL_VOTE:
+		d := CredentialVoteDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case ADD_VOTERS, REMOVE_VOTERS:
+		d := VotersDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case ELECTION_STATUS:
+		d := ElectionStatusDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case MERGE_WRITE_INS:
+		d := MergeWriteInsDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case PAUSE, RESUME:
+		d := PauseDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case CANCEL_POLL:
+		d := CancelPollDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case INITIATIVE:
+		d := InitiativeDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case SUPPORT:
+		d := SupportDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case REGISTRATION:
+		d := RegistrationDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case APPROVE, REJECT:
+		d := RegistrationDecisionDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case REPLACE_KEY:
+		d := ReplaceKeyDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case REDEEM_INVITATION:
+		d := RedeemInvitationDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case COMMIT_INVITATION:
+		d := CommitInvitationDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case EMBARGO_OVERRIDE:
+		d := EmbargoOverrideDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case KEY_DEALING:
+		d := KeyDealingDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
+	case KEY_COMPLAINT:
+		d := KeyComplaintDeliveryData{}
+		json.Unmarshal(b, &d)
+		out, _ = json.Marshal(d)
 	default:
-		return out, errors.New("The type for the delivery can only be 'election', 'poll' or 'vote'.")
+		return out, errDeliveryType
 	}
 	return out, nil
 }
@@ -127,96 +450,792 @@ type VoteDeliveryData struct {
 	From     string
 	PollHash string
 	Choice   string
+	Proof    MerkleProof `json:",omitempty"`
+
+	// the answers are the selected choices for each question of the poll, instead of the choice
+	Answers map[string][]string `json:",omitempty"`
+
+	// the write-in is a candidate that is not in the poll's choices, instead of the choice
+	WriteIn string `json:",omitempty"`
+
+	// the ranking has all the choices from the most to the least preferred, for the schulze method
+	Ranking []string `json:",omitempty"`
+
+	// the scores rate all the choices, for the score method
+	Scores map[string]int `json:",omitempty"`
+
+	// the quadratic votes are the votes for each choice, and n votes cost n*n voice credits
+	QuadraticVotes map[string]int `json:",omitempty"`
+
+	// the projects are the approved choices, and their costs fit in the budget, for the budgeting method
+	Projects []string `json:",omitempty"`
+}
+
+// ballotParts returns how many kinds of ballot the vote has, because it should have only one
+func (self *VoteDeliveryData) ballotParts() int {
+	n := 0
+	for _, l := range []int{len(self.Choice), len(self.Answers), len(self.WriteIn), len(self.Ranking), len(self.Scores),
+		len(self.QuadraticVotes), len(self.Projects)} {
+		if l > 0 {
+			n += 1
+		}
+	}
+	return n
 }
 
 func (self *VoteDeliveryData) GetFrom() string {
 	return self.From
 }
 
-type PollDeliveryData struct {
+// EncryptedVoteDeliveryData has a ciphertext for each choice of the poll,
+// the ciphertext encrypts 1 for the selected choice and 0 for the rest.
+type EncryptedVoteDeliveryData struct {
+	From     string
+	PollHash string
+	EncryptedBallot
+	Proof MerkleProof `json:",omitempty"`
+}
+
+func (self *EncryptedVoteDeliveryData) GetFrom() string {
+	return self.From
+}
+
+// AnonymousVoteDeliveryData does not have the voter's public key,
+// because the delivery's signature is a ring signature from the election's voters.
+// The key image is unique for each voter and poll, so the voter can not vote twice.
+type AnonymousVoteDeliveryData struct {
+	PollHash string
+	Choice   string
+	KeyImage string
+	Answers  map[string][]string `json:",omitempty"`
+	WriteIn  string              `json:",omitempty"`
+	Ranking  []string            `json:",omitempty"`
+	Scores   map[string]int      `json:",omitempty"`
+
+	QuadraticVotes map[string]int `json:",omitempty"`
+	Projects       []string       `json:",omitempty"`
+}
+
+// GetVoteDeliveryData returns the vote with the key image in the place of the voter
+func (self *AnonymousVoteDeliveryData) GetVoteDeliveryData() VoteDeliveryData {
+	return VoteDeliveryData{From: self.KeyImage, PollHash: self.PollHash, Choice: self.Choice, Answers: self.Answers,
+		WriteIn: self.WriteIn, Ranking: self.Ranking, Scores: self.Scores, QuadraticVotes: self.QuadraticVotes, Projects: self.Projects}
+}
+
+// CredentialRequestDeliveryData has the voter's blinded voting key for the election
+type CredentialRequestDeliveryData struct {
 	From       string
-	PollHash   string
 	ElectionID string
+	Blinded    string
+	Proof      MerkleProof `json:",omitempty"`
 }
 
-func (self *PollDeliveryData) GetFrom() string {
+func (self *CredentialRequestDeliveryData) GetFrom() string {
 	return self.From
 }
 
-func (p *PollDeliveryData) ValidateGonverment() error {
-	if p.From != confs.Conf.GonvermentPublicKeyHex {
+// CredentialDeliveryData has the gonverment's blind signature for the voter's request
+type CredentialDeliveryData struct {
+	From           string
+	ElectionID     string
+	Voter          string
+	BlindSignature string
+}
+
+func (self *CredentialDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (c *CredentialDeliveryData) ValidateGonverment() error {
+	if c.From != confs.Conf.GonvermentPublicKeyHex {
 		return errors.New("You are not a gonverment.")
 	}
 	return nil
 }
 
-type PollJson struct {
-	Description string
-	Choices     map[string]string
+// CredentialVoteDeliveryData is signed by the voting key and not by the voter's key,
+// and the credential is the gonverment's unblinded signature for the voting key.
+type CredentialVoteDeliveryData struct {
+	From       string
+	PollHash   string
+	Choice     string
+	Credential string
+	Answers    map[string][]string `json:",omitempty"`
+	WriteIn    string              `json:",omitempty"`
+	Ranking    []string            `json:",omitempty"`
+	Scores     map[string]int      `json:",omitempty"`
+
+	QuadraticVotes map[string]int `json:",omitempty"`
+	Projects       []string       `json:",omitempty"`
 }
 
-func (p *PollDeliveryData) GetPollJsonFromPollHash() (*PollJson, error) {
-	sh := shell.NewShell(confs.Conf.IpfsConnection)
-	objLs, err := sh.FileList(p.PollHash)
-	if err != nil {
-		return nil, errors.New("Folder from IPFS hash " + p.PollHash + " could not list files: " + err.Error())
-	}
-	pollJsonFound := false
-	hash := ""
-	for _, v := range objLs.Links {
-		if v.Name == "poll.json" {
-			hash = v.Hash
-			pollJsonFound = true
-		}
-	}
-	if !pollJsonFound {
-		return nil, errors.New("Folder from IPFS hash " + p.PollHash + " could not find the poll.json in the folder.")
-	}
+// GetVoteDeliveryData returns the vote with the voting key in the place of the voter
+func (self *CredentialVoteDeliveryData) GetVoteDeliveryData() VoteDeliveryData {
+	return VoteDeliveryData{From: self.From, PollHash: self.PollHash, Choice: self.Choice, Answers: self.Answers,
+		WriteIn: self.WriteIn, Ranking: self.Ranking, Scores: self.Scores, QuadraticVotes: self.QuadraticVotes, Projects: self.Projects}
+}
 
-	pollFile := uuid.NewV4().String()
-	err = sh.Get(hash, pollFile)
-	if err != nil {
-		return nil, errors.New("Failed to get file poll.json with hash " + p.PollHash + ": " + err.Error())
-	}
-	b, err := ioutil.ReadFile(pollFile)
-	if err != nil {
-		return nil, errors.New("Failed to read file poll.json with hash " + p.PollHash + ": " + err.Error())
-	}
-	os.RemoveAll(pollFile)
-	pj := PollJson{}
-	err = json.Unmarshal(b, &pj)
-	if err != nil {
-		return nil, errors.New("The poll.json has not the correct JSON format: " + err.Error())
-	}
-	if len(pj.Description) == 0 {
-		return nil, errors.New("The poll.json has empty description.")
-	}
-	if len(pj.Choices) == 0 {
-		return nil, errors.New("The poll.json has empty choices.")
-	}
-	return &pj, nil
+func (self *CredentialVoteDeliveryData) GetFrom() string {
+	return self.From
 }
 
-type ElectionDeliveryData struct {
-	ID     string
-	From   string
-	Voters []string
+// VotersDeliveryData has the voters that the gonverment adds to or removes from the election
+type VotersDeliveryData struct {
+	From       string
+	ElectionID string
+	Voters     []string
+
+	// the groups of the added voters
+	Groups map[string][]string `json:",omitempty"`
 }
 
-func (self *ElectionDeliveryData) GetFrom() string {
+func (self *VotersDeliveryData) GetFrom() string {
 	return self.From
 }
 
-func (e *ElectionDeliveryData) ValidateGonverment() error {
-	if e.From != confs.Conf.GonvermentPublicKeyHex {
+func (v *VotersDeliveryData) ValidateGonverment() error {
+	if v.From != confs.Conf.GonvermentPublicKeyHex {
 		return errors.New("You are not a gonverment.")
 	}
 	return nil
 }
 
-func (e *ElectionDeliveryData) ValidateVoters() error {
+func (v *VotersDeliveryData) ValidateVoters() error {
+	if len(v.Voters) == 0 {
+		return errors.New("The voters are empty.")
+	}
 	voters := map[string]int{}
-	for _, v := range e.Voters {
+	for _, pubHex := range v.Voters {
+		err := validatePublicKey(pubHex)
+		if err != nil {
+			return errors.New("The voter " + pubHex + " has not a correct public key: " + err.Error())
+		}
+		_, ok := voters[pubHex]
+		if ok {
+			return errors.New("The voter " + pubHex + " exists already in the list.")
+		}
+		voters[pubHex] = 0
+	}
+	return nil
+}
+
+// ElectionStatusDeliveryData moves the election to the next state of its lifecycle
+type ElectionStatusDeliveryData struct {
+	From       string
+	ElectionID string
+	Status     ElectionStatus
+}
+
+func (self *ElectionStatusDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (e *ElectionStatusDeliveryData) ValidateGonverment() error {
+	if e.From != confs.Conf.GonvermentPublicKeyHex {
+		return errors.New("You are not a gonverment.")
+	}
+	return nil
+}
+
+// MergeWriteInsDeliveryData merges the variants of a write-in into the candidate,
+// and the candidate can be one of the poll's choices or a write-in.
+type MergeWriteInsDeliveryData struct {
+	From      string
+	PollHash  string
+	Variants  []string
+	Candidate string
+}
+
+func (self *MergeWriteInsDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (m *MergeWriteInsDeliveryData) ValidateGonverment() error {
+	if m.From != confs.Conf.GonvermentPublicKeyHex {
+		return errors.New("You are not a gonverment.")
+	}
+	return nil
+}
+
+// PauseDeliveryData pauses or resumes the votes of an election or a poll
+type PauseDeliveryData struct {
+	From       string
+	ElectionID string `json:",omitempty"`
+	PollHash   string `json:",omitempty"`
+	Reason     string `json:",omitempty"`
+}
+
+func (self *PauseDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (p *PauseDeliveryData) ValidateGonverment() error {
+	if p.From != confs.Conf.GonvermentPublicKeyHex {
+		return errors.New("You are not a gonverment.")
+	}
+	return nil
+}
+
+const pauseReasonMaxSize = 500
+
+func (p *PauseDeliveryData) ValidateReason() error {
+	if len(p.Reason) == 0 {
+		return errors.New("The pause's reason is empty.")
+	}
+	if len(p.Reason) > pauseReasonMaxSize {
+		return errors.New("The pause's reason is bigger than 500 bytes.")
+	}
+	return nil
+}
+
+// CancelPollDeliveryData cancels the poll, and the replacement is the poll that corrects it
+type CancelPollDeliveryData struct {
+	From        string
+	PollHash    string
+	Reason      string
+	Replacement string `json:",omitempty"`
+}
+
+func (self *CancelPollDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (c *CancelPollDeliveryData) ValidateGonverment() error {
+	if c.From != confs.Conf.GonvermentPublicKeyHex {
+		return errors.New("You are not a gonverment.")
+	}
+	return nil
+}
+
+const cancelReasonMaxSize = 500
+
+func (c *CancelPollDeliveryData) ValidateReason() error {
+	if len(c.Reason) == 0 {
+		return errors.New("The cancellation's reason is empty.")
+	}
+	if len(c.Reason) > cancelReasonMaxSize {
+		return errors.New("The cancellation's reason is bigger than 500 bytes.")
+	}
+	return nil
+}
+
+// InitiativeDeliveryData is a voter's proposal for a poll in the election
+type InitiativeDeliveryData struct {
+	From       string
+	ElectionID string
+	PollHash   string
+	Proof      MerkleProof `json:",omitempty"`
+}
+
+func (self *InitiativeDeliveryData) GetFrom() string {
+	return self.From
+}
+
+// SupportDeliveryData is a voter's signature for the initiative
+type SupportDeliveryData struct {
+	From     string
+	PollHash string
+	Proof    MerkleProof `json:",omitempty"`
+}
+
+func (self *SupportDeliveryData) GetFrom() string {
+	return self.From
+}
+
+// RegistrationDeliveryData is the request of a voter to be in the draft election,
+// and the attributes help the gonverment to decide, like the name or the address
+type RegistrationDeliveryData struct {
+	From       string
+	ElectionID string
+	Attributes map[string]string `json:",omitempty"`
+}
+
+func (self *RegistrationDeliveryData) GetFrom() string {
+	return self.From
+}
+
+const (
+	registrationAttributesMax = 20
+	attributeNameMaxSize      = 100
+	attributeValueMaxSize     = 500
+)
+
+func (r *RegistrationDeliveryData) ValidateAttributes() error {
+	if len(r.Attributes) > registrationAttributesMax {
+		return errors.New("The registration can not have more than 20 attributes.")
+	}
+	for k, v := range r.Attributes {
+		if len(k) == 0 || len(k) > attributeNameMaxSize {
+			return errors.New("The attribute's name should be from 1 to 100 bytes.")
+		}
+		if len(v) > attributeValueMaxSize {
+			return errors.New("The attribute " + k + " is bigger than 500 bytes.")
+		}
+	}
+	return nil
+}
+
+// RegistrationDecisionDeliveryData approves or rejects the voter's registration
+type RegistrationDecisionDeliveryData struct {
+	From       string
+	ElectionID string
+	Voter      string
+	Reason     string `json:",omitempty"`
+}
+
+func (self *RegistrationDecisionDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (r *RegistrationDecisionDeliveryData) ValidateGonverment() error {
+	if r.From != confs.Conf.GonvermentPublicKeyHex {
+		return errors.New("You are not a gonverment.")
+	}
+	return nil
+}
+
+const rejectReasonMaxSize = 500
+
+func (r *RegistrationDecisionDeliveryData) ValidateReason() error {
+	if len(r.Reason) > rejectReasonMaxSize {
+		return errors.New("The rejection's reason is bigger than 500 bytes.")
+	}
+	return nil
+}
+
+// ReplaceKeyDeliveryData replaces the key of a voter that lost it, and the new key keeps the votes of the old key
+type ReplaceKeyDeliveryData struct {
+	From       string
+	ElectionID string
+	OldVoter   string
+	NewVoter   string
+}
+
+func (self *ReplaceKeyDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (r *ReplaceKeyDeliveryData) ValidateGonverment() error {
+	if r.From != confs.Conf.GonvermentPublicKeyHex {
+		return errors.New("You are not a gonverment.")
+	}
+	return nil
+}
+
+// RedeemInvitationDeliveryData binds the voter's new key to the election with the invitation's code
+type RedeemInvitationDeliveryData struct {
+	From       string
+	ElectionID string
+	Code       string
+}
+
+func (self *RedeemInvitationDeliveryData) GetFrom() string {
+	return self.From
+}
+
+// InvitationHash returns the hash of the invitation's code as hex, which is in the election instead of the code
+func InvitationHash(code string) string {
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
+
+// CommitInvitationDeliveryData commits the voter's key to an invitation's code before the code is redeemed,
+// because the redemption shows the code in the mempool
+type CommitInvitationDeliveryData struct {
+	From       string
+	ElectionID string
+	Commitment string
+}
+
+func (self *CommitInvitationDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (self *CommitInvitationDeliveryData) ValidateCommitment() error {
+	b, err := hex.DecodeString(self.Commitment)
+	if err != nil || len(b) != sha256.Size || self.Commitment != strings.ToLower(self.Commitment) {
+		return errors.New("The invitation's commitment is not a correct hash.")
+	}
+	return nil
+}
+
+// InvitationCommitmentHash returns the hash of the code's hash with the voter's public key as hex,
+// so the commitment does not show the code and it is only for the voter's key
+func InvitationCommitmentHash(code, voter string) string {
+	h := sha256.Sum256([]byte(InvitationHash(code) + voter))
+	return hex.EncodeToString(h[:])
+}
+
+// EmbargoOverrideDeliveryData lifts the embargo of the poll's results before the poll closes,
+// and only the gonverment or an auditor of the election can do it with a reason
+type EmbargoOverrideDeliveryData struct {
+	From     string
+	PollHash string
+	Reason   string
+}
+
+func (self *EmbargoOverrideDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (e *EmbargoOverrideDeliveryData) ValidateGonvermentOrAuditor(es *ElectionState) error {
+	if e.From != confs.Conf.GonvermentPublicKeyHex && !es.IsAuditor(e.From) {
+		return errors.New("You are not the gonverment or an auditor of the election.")
+	}
+	return nil
+}
+
+const overrideReasonMaxSize = 500
+
+func (e *EmbargoOverrideDeliveryData) ValidateReason() error {
+	if len(e.Reason) == 0 {
+		return errors.New("The override's reason is empty.")
+	}
+	if len(e.Reason) > overrideReasonMaxSize {
+		return errors.New("The override's reason is bigger than 500 bytes.")
+	}
+	return nil
+}
+
+const writeInMaxSize = 100
+
+// NormalizeWriteIn lowers the letters and keeps only one space between the words,
+// so the same candidate is written with the same way.
+func NormalizeWriteIn(w string) string {
+	return strings.ToLower(strings.Join(strings.Fields(w), " "))
+}
+
+func ValidateWriteIn(w string) error {
+	if len(w) == 0 {
+		return errors.New("The write-in is empty.")
+	}
+	if len(w) > writeInMaxSize {
+		return errors.New("The write-in is bigger than 100 bytes.")
+	}
+	if !utf8.ValidString(w) {
+		return errors.New("The write-in is not UTF-8.")
+	}
+	if NormalizeWriteIn(w) != w {
+		return errors.New("The write-in is not normalized.")
+	}
+	return nil
+}
+
+// DecryptionDeliveryData has the trustee's decryption share for each choice of the poll
+type DecryptionDeliveryData struct {
+	From     string
+	PollHash string
+	Shares   map[string]DecryptionShare
+}
+
+func (self *DecryptionDeliveryData) GetFrom() string {
+	return self.From
+}
+
+// KeyDealingDeliveryData is the trustee's dealing for the election's key
+type KeyDealingDeliveryData struct {
+	From       string
+	ElectionID string
+	Dealing    TrusteeDealing
+}
+
+func (self *KeyDealingDeliveryData) GetFrom() string {
+	return self.From
+}
+
+// KeyComplaintDeliveryData reveals the key of the share that the dealer sent to the trustee,
+// with the proof that the key is correct, so the chain can check the share
+type KeyComplaintDeliveryData struct {
+	From       string
+	ElectionID string
+	Dealer     string
+	Key        string
+	Proof      EqualityProof
+}
+
+func (self *KeyComplaintDeliveryData) GetFrom() string {
+	return self.From
+}
+
+type PollDeliveryData struct {
+	From       string
+	PollHash   string
+	ElectionID string
+
+	// the poll does not accept votes after the block of the end height, and without it the poll ends with a newer poll
+	EndHeight int64 `json:",omitempty"`
+
+	// the scheduled poll is pending until the block of the activation's height or time (unix seconds)
+	ActivationHeight int64 `json:",omitempty"`
+	ActivationTime   int64 `json:",omitempty"`
+}
+
+func (p *PollDeliveryData) IsScheduled() bool {
+	return p.ActivationHeight > 0 || p.ActivationTime > 0
+}
+
+func (p *PollDeliveryData) ValidateActivation(height, time int64) error {
+	if p.ActivationHeight < 0 || p.ActivationTime < 0 {
+		return errors.New("The poll's activation can not be negative.")
+	}
+	if p.ActivationHeight > 0 && p.ActivationTime > 0 {
+		return errors.New("The poll can be activated at a height or at a time, but not both.")
+	}
+	if p.ActivationHeight > 0 && p.ActivationHeight <= height {
+		return errors.New("The poll's activation height should be after the current height.")
+	}
+	if p.ActivationTime > 0 && p.ActivationTime <= time {
+		return errors.New("The poll's activation time should be after the current time.")
+	}
+	if p.ActivationHeight > 0 && p.EndHeight != 0 && p.EndHeight <= p.ActivationHeight {
+		return errors.New("The poll's end height should be after the activation height.")
+	}
+	return nil
+}
+
+func (self *PollDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (p *PollDeliveryData) ValidateGonverment() error {
+	if p.From != confs.Conf.GonvermentPublicKeyHex {
+		return errors.New("You are not a gonverment.")
+	}
+	return nil
+}
+
+type PollJson struct {
+	Description string
+	Choices     map[string]string
+
+	// the poll can have questions instead of choices, and the vote answers all of them
+	Questions map[string]QuestionJson `json:",omitempty"`
+
+	// the voters can write a candidate that is not in the choices
+	WriteIns bool `json:",omitempty"`
+
+	// the method counts the votes, and by default it is the plurality
+	Method   VotingMethod `json:",omitempty"`
+	ScoreMax int          `json:",omitempty"`
+
+	// the voice credits are the budget of each voter for the quadratic method
+	VoiceCredits int `json:",omitempty"`
+
+	// the costs of the choices and the total budget for the budgeting method
+	Costs  map[string]int `json:",omitempty"`
+	Budget int            `json:",omitempty"`
+
+	// only the voters of the groups can vote
+	Groups []string `json:",omitempty"`
+
+	// the results are hidden, except the turnout, until the poll closes
+	Embargo bool `json:",omitempty"`
+
+	// when the poll ends and no choice has more than this percentage of the votes,
+	// the two choices with the most votes go to a runoff poll
+	RunoffThreshold int `json:",omitempty"`
+}
+
+const runoffThresholdLimit = 99
+
+func (pj *PollJson) GetMethod() VotingMethod {
+	if len(pj.Method) == 0 {
+		return METHOD_PLURALITY
+	}
+	return pj.Method
+}
+
+// GetVoiceCredits returns the budget of each voter for the quadratic method, by default is 100
+func (pj *PollJson) GetVoiceCredits() int {
+	if pj.VoiceCredits == 0 {
+		return defaultVoiceCredits
+	}
+	return pj.VoiceCredits
+}
+
+// GetScoreMax returns the highest score of the score method, by default is 5
+func (pj *PollJson) GetScoreMax() int {
+	if pj.ScoreMax == 0 {
+		return defaultScoreMax
+	}
+	return pj.ScoreMax
+}
+
+// QuestionJson has the question's choices and how many of them the voter can select
+type QuestionJson struct {
+	Description   string
+	Choices       map[string]string
+	MinSelections int `json:",omitempty"`
+	MaxSelections int `json:",omitempty"` // the default is one
+}
+
+func (q *QuestionJson) GetMaxSelections() int {
+	if q.MaxSelections == 0 {
+		return 1
+	}
+	return q.MaxSelections
+}
+
+func (pj *PollJson) Validate() error {
+	if len(pj.Description) == 0 {
+		return errors.New("The poll.json has empty description.")
+	}
+	switch pj.GetMethod() {
+	case METHOD_PLURALITY:
+	case METHOD_SCHULZE, METHOD_SCORE, METHOD_QUADRATIC, METHOD_BUDGETING:
+		if len(pj.Questions) > 0 || pj.WriteIns {
+			return errors.New("The poll.json with the " + string(pj.Method) + " method can not have questions or write-ins.")
+		}
+	default:
+		return errors.New("The poll.json's method can only be 'plurality', 'schulze', 'score', 'quadratic' or 'budgeting'.")
+	}
+	if pj.ScoreMax != 0 && pj.GetMethod() != METHOD_SCORE {
+		return errors.New("The poll.json can have the score's max only for the score method.")
+	}
+	if pj.ScoreMax < 0 || pj.ScoreMax > scoreMaxLimit {
+		return errors.New("The poll.json's score max should be from 1 to 100.")
+	}
+	if pj.VoiceCredits != 0 && pj.GetMethod() != METHOD_QUADRATIC {
+		return errors.New("The poll.json can have the voice credits only for the quadratic method.")
+	}
+	if pj.VoiceCredits < 0 || pj.VoiceCredits > voiceCreditsLimit {
+		return errors.New("The poll.json's voice credits should be from 1 to 10000.")
+	}
+	err := ValidateGroupNames(pj.Groups)
+	if err != nil {
+		return err
+	}
+	if pj.RunoffThreshold != 0 {
+		if pj.GetMethod() != METHOD_PLURALITY || len(pj.Questions) > 0 {
+			return errors.New("The poll.json can have a runoff only for the plurality method without questions.")
+		}
+		if pj.RunoffThreshold < 0 || pj.RunoffThreshold > runoffThresholdLimit {
+			return errors.New("The poll.json's runoff threshold should be from 1 to 99 percent.")
+		}
+		if len(pj.Choices) < 3 {
+			return errors.New("The poll.json with a runoff should have more than two choices.")
+		}
+	}
+	if pj.GetMethod() == METHOD_BUDGETING {
+		err := ValidateCosts(pj.Choices, pj.Costs, pj.Budget)
+		if err != nil {
+			return err
+		}
+	} else if len(pj.Costs) > 0 || pj.Budget != 0 {
+		return errors.New("The poll.json can have the costs and the budget only for the budgeting method.")
+	}
+	if len(pj.Questions) == 0 {
+		if len(pj.Choices) == 0 {
+			return errors.New("The poll.json has empty choices.")
+		}
+		return nil
+	}
+	if len(pj.Choices) > 0 {
+		return errors.New("The poll.json can have the choices or the questions, but not both.")
+	}
+	if pj.WriteIns {
+		return errors.New("The poll.json with questions can not have write-ins.")
+	}
+	for k, v := range pj.Questions {
+		if len(v.Description) == 0 {
+			return errors.New("The question " + k + " has empty description.")
+		}
+		if len(v.Choices) == 0 {
+			return errors.New("The question " + k + " has empty choices.")
+		}
+		if v.MinSelections < 0 || v.MinSelections > v.GetMaxSelections() || v.GetMaxSelections() > len(v.Choices) {
+			return errors.New("The question " + k + " has not correct number of selections.")
+		}
+	}
+	return nil
+}
+
+func (p *PollDeliveryData) GetPollJsonFromPollHash() (*PollJson, error) {
+	sh := shell.NewShell(confs.Conf.IpfsConnection)
+	objLs, err := sh.FileList(p.PollHash)
+	if err != nil {
+		return nil, errors.New("Folder from IPFS hash " + p.PollHash + " could not list files: " + err.Error())
+	}
+	pollJsonFound := false
+	hash := ""
+	for _, v := range objLs.Links {
+		if v.Name == "poll.json" {
+			hash = v.Hash
+			pollJsonFound = true
+		}
+	}
+	if !pollJsonFound {
+		return nil, errors.New("Folder from IPFS hash " + p.PollHash + " could not find the poll.json in the folder.")
+	}
+
+	pollFile := uuid.NewV4().String()
+	err = sh.Get(hash, pollFile)
+	if err != nil {
+		return nil, errors.New("Failed to get file poll.json with hash " + p.PollHash + ": " + err.Error())
+	}
+	b, err := ioutil.ReadFile(pollFile)
+	if err != nil {
+		return nil, errors.New("Failed to read file poll.json with hash " + p.PollHash + ": " + err.Error())
+	}
+	os.RemoveAll(pollFile)
+	pj := PollJson{}
+	err = json.Unmarshal(b, &pj)
+	if err != nil {
+		return nil, errors.New("The poll.json has not the correct JSON format: " + err.Error())
+	}
+	err = pj.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &pj, nil
+}
+
+type ElectionDeliveryData struct {
+	ID          string
+	From        string
+	Voters      []string
+	Encryption  *ElectionEncryption `json:",omitempty"`
+	Anonymous   bool                `json:",omitempty"`
+	Credentials *CredentialKey      `json:",omitempty"`
+
+	// the election can have the Merkle root of the voters instead of the voters
+	VotersRoot  string `json:",omitempty"`
+	VotersCount int    `json:",omitempty"`
+
+	// the draft election can change its voters, but it can not have polls until it opens
+	Draft bool `json:",omitempty"`
+
+	Name         string `json:",omitempty"`
+	Description  string `json:",omitempty"`
+	Jurisdiction string `json:",omitempty"`
+
+	// the groups of each voter, like a district or a department
+	Groups map[string][]string `json:",omitempty"`
+
+	// the percentage of the voters that should support a voter's initiative, so it becomes a poll
+	InitiativeShare int `json:",omitempty"`
+
+	// the hashes of the invitations' codes, and each code adds one voter with the voter's key
+	Invitations []string `json:",omitempty"`
+
+	// the auditors' public keys, that can override the embargo of the polls' results
+	Auditors []string `json:",omitempty"`
+}
+
+func (self *ElectionDeliveryData) GetFrom() string {
+	return self.From
+}
+
+func (e *ElectionDeliveryData) ValidateGonverment() error {
+	if e.From != confs.Conf.GonvermentPublicKeyHex {
+		return errors.New("You are not a gonverment.")
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) ValidateVoters() error {
+	voters := map[string]int{}
+	for _, v := range e.Voters {
 		pubB, err := hex.DecodeString(v)
 		if err != nil {
 			return errors.New("The voter " + v + " is not a correct hex: " + err.Error())
@@ -233,3 +1252,192 @@ func (e *ElectionDeliveryData) ValidateVoters() error {
 	}
 	return nil
 }
+
+const (
+	electionNameMaxSize        = 200
+	electionDescriptionMaxSize = 5000
+)
+
+// the election's ID can not be a fixed route under /elections/, because the query of its details is /elections/{id}
+var reservedElectionIDs = []string{"latest", "amendments", "encryption"}
+
+func (e *ElectionDeliveryData) ValidateID() error {
+	if strings.Contains(e.ID, "/") {
+		return errors.New("The election's ID can not have the '/'.")
+	}
+	for _, v := range reservedElectionIDs {
+		if e.ID == v {
+			return errors.New("The election's ID '" + v + "' is reserved.")
+		}
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) ValidateMetadata() error {
+	if len(e.Name) > electionNameMaxSize {
+		return errors.New("The election's name is bigger than 200 bytes.")
+	}
+	if len(e.Jurisdiction) > electionNameMaxSize {
+		return errors.New("The election's jurisdiction is bigger than 200 bytes.")
+	}
+	if len(e.Description) > electionDescriptionMaxSize {
+		return errors.New("The election's description is bigger than 5000 bytes.")
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) ValidateVotersRoot() error {
+	if len(e.VotersRoot) == 0 {
+		return nil
+	}
+	if len(e.Voters) > 0 {
+		return errors.New("The election can have the voters or the voters' root, but not both.")
+	}
+	b, err := hex.DecodeString(e.VotersRoot)
+	if err != nil || len(b) != 32 {
+		return errors.New("The voters' root is not a correct hash.")
+	}
+	if e.VotersCount <= 0 {
+		return errors.New("The number of voters for the voters' root is missing.")
+	}
+	if e.Anonymous {
+		return errors.New("The anonymous election needs the voters and not the voters' root.")
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) NumberOfVoters() int {
+	if len(e.VotersRoot) > 0 {
+		return e.VotersCount
+	}
+	return len(e.Voters)
+}
+
+func (e *ElectionDeliveryData) ValidateAuditors() error {
+	auditors := map[string]bool{}
+	for _, v := range e.Auditors {
+		err := validatePublicKey(v)
+		if err != nil {
+			return errors.New("The auditor " + v + " has not a correct public key: " + err.Error())
+		}
+		if auditors[v] {
+			return errors.New("The auditor " + v + " exists already in the list.")
+		}
+		auditors[v] = true
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) ValidateInvitations() error {
+	if len(e.Invitations) == 0 {
+		return nil
+	}
+	if len(e.VotersRoot) > 0 {
+		return errors.New("The election with the voters' root can not have invitations.")
+	}
+	invitations := map[string]bool{}
+	for _, v := range e.Invitations {
+		// the redemption finds the code's hash in lowercase hex
+		b, err := hex.DecodeString(v)
+		if err != nil || len(b) != sha256.Size || v != strings.ToLower(v) {
+			return errors.New("The invitation " + v + " is not a correct hash.")
+		}
+		if invitations[v] {
+			return errors.New("The invitation " + v + " exists already in the list.")
+		}
+		invitations[v] = true
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) ValidateInitiativeShare() error {
+	if e.InitiativeShare < 0 || e.InitiativeShare > 100 {
+		return errors.New("The initiative's share should be from 1 to 100 percent.")
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) ValidateGroups() error {
+	if len(e.Groups) == 0 {
+		return nil
+	}
+	if e.Encryption != nil || e.Anonymous || e.Credentials != nil {
+		return errors.New("The election with groups can not be encrypted, anonymous or with credentials, because the votes are counted for each group.")
+	}
+	return ValidateVoterGroups(e.Voters, e.Groups)
+}
+
+const groupMaxSize = 100
+
+// ValidateVoterGroups checks that the groups are for the voters of the list
+func ValidateVoterGroups(voters []string, groups map[string][]string) error {
+	listed := map[string]bool{}
+	for _, v := range voters {
+		listed[v] = true
+	}
+	for v, gs := range groups {
+		if !listed[v] {
+			return errors.New("The voter " + v + " of the groups is not in the voters.")
+		}
+		if len(gs) == 0 {
+			return errors.New("The voter " + v + " has empty groups.")
+		}
+		err := ValidateGroupNames(gs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ValidateGroupNames(groups []string) error {
+	names := map[string]bool{}
+	for _, g := range groups {
+		if len(g) == 0 || len(g) > groupMaxSize {
+			return errors.New("The group's name should be from 1 to 100 bytes.")
+		}
+		if names[g] {
+			return errors.New("The group " + g + " exists twice.")
+		}
+		names[g] = true
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) ValidateAnonymous() error {
+	if !e.Anonymous {
+		return nil
+	}
+	if e.Encryption != nil {
+		return errors.New("The election can not be anonymous and encrypted.")
+	}
+	for _, v := range e.Voters {
+		_, err := ringPublicKey(v)
+		if err != nil {
+			return errors.New("The voter " + v + " can not be in an anonymous election: " + err.Error())
+		}
+	}
+	return nil
+}
+
+func (e *ElectionDeliveryData) ValidateCredentials() error {
+	if e.Credentials == nil {
+		return nil
+	}
+	if e.Encryption != nil || e.Anonymous {
+		return errors.New("The election with credentials can not be anonymous or encrypted.")
+	}
+	return e.Credentials.Validate()
+}
+
+func validatePublicKey(pubHex string) error {
+	pubB, err := hex.DecodeString(pubHex)
+	if err != nil {
+		return errors.New("The public key is not correct hex: " + err.Error())
+	}
+	_, err = crypto.UnmarshalPublicKey(pubB)
+	if err != nil {
+		return errors.New("The public key is not correct")
+	}
+	return nil
+}
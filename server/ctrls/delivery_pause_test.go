@@ -0,0 +1,83 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestPause(t *testing.T, app *TVApplication, privk crypto.PrivKey, dt DeliveryType, pd PauseDeliveryData) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+	pd.From = hex.EncodeToString(pubB)
+	return forTestDeliver(t, app, privk, dt, &pd)
+}
+
+func TestPauseFailOnWrongDelivery(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice"})
+
+	// not the gonverment
+	assert.Equal(t, CodeTypeUnauthorized, forTestPause(t, app, voters[0], PAUSE, PauseDeliveryData{PollHash: pollHash, Reason: "wrong option"}))
+	// without reason
+	assert.Equal(t, CodeTypeUnauthorized, forTestPause(t, app, gov, PAUSE, PauseDeliveryData{PollHash: pollHash}))
+	// both the election and the poll
+	assert.Equal(t, CodeTypeUnauthorized, forTestPause(t, app, gov, PAUSE, PauseDeliveryData{ElectionID: electionID, PollHash: pollHash, Reason: "wrong option"}))
+	// resume when it is not paused
+	assert.Equal(t, CodeTypeUnauthorized, forTestPause(t, app, gov, RESUME, PauseDeliveryData{PollHash: pollHash}))
+
+	assert.Equal(t, CodeTypeOK, forTestPause(t, app, gov, PAUSE, PauseDeliveryData{PollHash: pollHash, Reason: "wrong option"}))
+	// pause twice
+	assert.Equal(t, CodeTypeUnauthorized, forTestPause(t, app, gov, PAUSE, PauseDeliveryData{PollHash: pollHash, Reason: "wrong option"}))
+}
+
+func TestPausePollSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice"})
+
+	app.state.Height = 7
+	assert.Equal(t, CodeTypeOK, forTestPause(t, app, gov, PAUSE, PauseDeliveryData{PollHash: pollHash, Reason: "wrong option"}))
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypePaused, forTestDeliver(t, app, voters[0], VOTE, &vd))
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, &PauseState{Reason: "wrong option", Height: 7}, pvq.Paused)
+
+	assert.Equal(t, CodeTypeOK, forTestPause(t, app, gov, RESUME, PauseDeliveryData{PollHash: pollHash}))
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	pvq, err = app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Nil(t, pvq.Paused)
+	assert.Equal(t, 1, pvq.Choices["a"])
+}
+
+func TestPauseElectionSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice"})
+
+	assert.Equal(t, CodeTypeOK, forTestPause(t, app, gov, PAUSE, PauseDeliveryData{ElectionID: electionID, Reason: "investigation"}))
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypePaused, forTestDeliver(t, app, voters[0], VOTE, &vd))
+
+	edq, err := app.queryElectionDetails(electionID)
+	assert.Nil(t, err)
+	assert.Equal(t, "investigation", edq.Paused.Reason)
+
+	assert.Equal(t, CodeTypeOK, forTestPause(t, app, gov, RESUME, PauseDeliveryData{ElectionID: electionID}))
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+}
@@ -0,0 +1,114 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestQuestionsPollJson() PollJson {
+	return PollJson{
+		Description: "referendum",
+		Questions: map[string]QuestionJson{
+			"law": {Description: "the new law", Choices: map[string]string{"y": "yes", "n": "no"}},
+			"parks": {
+				Description:   "the new parks",
+				Choices:       map[string]string{"a": "north", "b": "south", "c": "east"},
+				MaxSelections: 2,
+			},
+		},
+	}
+}
+
+func TestQuestionsPollFailOnChoicesAndQuestions(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+
+	pj := forTestQuestionsPollJson()
+	pj.Choices = map[string]string{"y": "yes"}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+}
+
+func TestQuestionsPollFailOnMoreSelectionsThanChoices(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+
+	pj := forTestQuestionsPollJson()
+	q := pj.Questions["parks"]
+	q.MaxSelections = 4
+	pj.Questions["parks"] = q
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+}
+
+func TestQuestionsPollFailOnEncryptedElection(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	_, _, enc := forTestTrustees(t, 1, 1)
+	electionID := forTestCreateEncryptedElection(t, app, gov, voterHexs, enc)
+
+	pollHash := forTestUploadPollJson(t, forTestQuestionsPollJson())
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+}
+
+func TestQuestionsVoteFailOnWrongAnswers(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestUploadPollJson(t, forTestQuestionsPollJson())
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+
+	wrongs := []VoteDeliveryData{
+		// the choice instead of the answers
+		{Choice: "y"},
+		// a question is not answered
+		{Answers: map[string][]string{"law": {"y"}}},
+		// more selections than the question allows
+		{Answers: map[string][]string{"law": {"y", "n"}, "parks": {"a"}}},
+		// the same selection twice
+		{Answers: map[string][]string{"law": {"y"}, "parks": {"a", "a"}}},
+		// a choice from another question
+		{Answers: map[string][]string{"law": {"y"}, "parks": {"y"}}},
+		// a question that does not exist
+		{Answers: map[string][]string{"law": {"y"}, "roads": {"a"}}},
+	}
+	for _, vd := range wrongs {
+		vd.From = voterHexs[0]
+		vd.PollHash = pollHash
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	}
+}
+
+func TestQuestionsVoteSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestUploadPollJson(t, forTestQuestionsPollJson())
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Answers: map[string][]string{"law": {"y"}, "parks": {"a", "c"}}}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	vd = VoteDeliveryData{From: voterHexs[1], PollHash: pollHash, Answers: map[string][]string{"law": {"n"}, "parks": {"c"}}}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[1], VOTE, &vd))
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pvq.NumberOfVotes)
+	assert.Equal(t, map[string]int{"y": 1, "n": 1}, pvq.Questions["law"])
+	assert.Equal(t, map[string]int{"a": 1, "b": 0, "c": 2}, pvq.Questions["parks"])
+}
@@ -0,0 +1,105 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestDecideRegistration(t *testing.T, app *TVApplication, gov crypto.PrivKey, dt DeliveryType, electionID, voter, reason string) uint32 {
+	pubB, _ := gov.GetPublic().Bytes()
+	rd := RegistrationDecisionDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, Voter: voter, Reason: reason}
+	return forTestDeliver(t, app, gov, dt, &rd)
+}
+
+func TestRegistrationFailOnOpenElection(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	others, otherHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+
+	rd := RegistrationDeliveryData{From: otherHexs[0], ElectionID: electionID}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, others[0], REGISTRATION, &rd))
+}
+
+func TestRegistrationFailOnWrongRequests(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	others, otherHexs := forTestVoters(t, 1)
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Voters: voterHexs, Draft: true})
+
+	// the voter exists already
+	rd := RegistrationDeliveryData{From: voterHexs[0], ElectionID: electionID}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], REGISTRATION, &rd))
+	// an empty attribute's name
+	rd = RegistrationDeliveryData{From: otherHexs[0], ElectionID: electionID, Attributes: map[string]string{"": "bob"}}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, others[0], REGISTRATION, &rd))
+	// the registration is pending already
+	rd = RegistrationDeliveryData{From: otherHexs[0], ElectionID: electionID}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, others[0], REGISTRATION, &rd))
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, others[0], REGISTRATION, &rd))
+}
+
+func TestRegistrationDecisionFailOnNotGonverment(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	others, otherHexs := forTestVoters(t, 1)
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Voters: voterHexs, Draft: true})
+
+	rd := RegistrationDeliveryData{From: otherHexs[0], ElectionID: electionID}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, others[0], REGISTRATION, &rd))
+	assert.Equal(t, CodeTypeUnauthorized, forTestDecideRegistration(t, app, voters[0], APPROVE, electionID, otherHexs[0], ""))
+	assert.Equal(t, CodeTypeUnauthorized, forTestDecideRegistration(t, app, others[0], APPROVE, electionID, otherHexs[0], ""))
+}
+
+func TestRegistrationSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	others, otherHexs := forTestVoters(t, 2)
+	electionID := forTestDeliverElection(t, app, gov, ElectionDeliveryData{Voters: voterHexs, Draft: true})
+
+	for i := range others {
+		rd := RegistrationDeliveryData{From: otherHexs[i], ElectionID: electionID, Attributes: map[string]string{"district": "north"}}
+		assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, others[i], REGISTRATION, &rd))
+	}
+	list, err := app.queryPendingRegistrations(electionID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, otherHexs[0], list[0].Voter)
+	assert.Equal(t, "north", list[0].Attributes["district"])
+
+	assert.Equal(t, CodeTypeOK, forTestDecideRegistration(t, app, gov, APPROVE, electionID, otherHexs[0], ""))
+	assert.Equal(t, CodeTypeOK, forTestDecideRegistration(t, app, gov, REJECT, electionID, otherHexs[1], "The address is wrong"))
+	// the decision is only once
+	assert.Equal(t, CodeTypeUnauthorized, forTestDecideRegistration(t, app, gov, REJECT, electionID, otherHexs[0], ""))
+
+	list, err = app.queryPendingRegistrations(electionID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(list))
+	es, err := app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	assert.True(t, es.HasVoter(otherHexs[0]))
+	assert.False(t, es.HasVoter(otherHexs[1]))
+	assert.Equal(t, 1, len(es.Amendments))
+	rs, err := app.state.GetRegistration(electionID, otherHexs[1])
+	assert.Nil(t, err)
+	assert.Equal(t, REGISTRATION_REJECTED, rs.Status)
+
+	// the rejected voter can request again
+	rd := RegistrationDeliveryData{From: otherHexs[1], ElectionID: electionID}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, others[1], REGISTRATION, &rd))
+	list, err = app.queryPendingRegistrations(electionID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list))
+}
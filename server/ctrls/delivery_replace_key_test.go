@@ -0,0 +1,77 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestReplaceKey(t *testing.T, app *TVApplication, privk crypto.PrivKey, electionID, oldVoter, newVoter string) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+	rd := ReplaceKeyDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, OldVoter: oldVoter, NewVoter: newVoter}
+	return forTestDeliver(t, app, privk, REPLACE_KEY, &rd)
+}
+
+func TestReplaceKeyFailOnWrongKeys(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	_, otherHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+
+	// not the gonverment
+	assert.Equal(t, CodeTypeUnauthorized, forTestReplaceKey(t, app, voters[0], electionID, voterHexs[0], otherHexs[0]))
+	// the old key is not a voter
+	assert.Equal(t, CodeTypeUnauthorized, forTestReplaceKey(t, app, gov, electionID, otherHexs[1], otherHexs[0]))
+	// the new key is a voter already
+	assert.Equal(t, CodeTypeUnauthorized, forTestReplaceKey(t, app, gov, electionID, voterHexs[0], voterHexs[1]))
+	// the new key is not a public key
+	assert.Equal(t, CodeTypeUnauthorized, forTestReplaceKey(t, app, gov, electionID, voterHexs[0], "abcd"))
+}
+
+func TestReplaceKeyFailOnAnonymousElection(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 2)
+	_, otherHexs := forTestVoters(t, 1)
+	electionID := forTestCreateAnonymousElection(t, app, gov, voterHexs)
+
+	assert.Equal(t, CodeTypeUnauthorized, forTestReplaceKey(t, app, gov, electionID, voterHexs[0], otherHexs[0]))
+}
+
+func TestReplaceKeySuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	others, otherHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice", "b": "bob"})
+	forTestCreateVote(t, app, voters[0], electionID, pollHash, "a")
+
+	assert.Equal(t, CodeTypeOK, forTestReplaceKey(t, app, gov, electionID, voterHexs[0], otherHexs[0]))
+	es, err := app.state.GetElection(electionID)
+	assert.Nil(t, err)
+	assert.False(t, es.HasVoter(voterHexs[0]))
+	assert.True(t, es.HasVoter(otherHexs[0]))
+	assert.Equal(t, 2, len(es.Voters))
+	assert.Equal(t, []string{otherHexs[0]}, es.Amendments[0].Added)
+	assert.Equal(t, []string{voterHexs[0]}, es.Amendments[0].Removed)
+
+	// the new key can not vote again in the poll that the old key voted
+	vd := VoteDeliveryData{From: otherHexs[0], PollHash: pollHash, Choice: "b"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, others[0], VOTE, &vd))
+	// the old key can not vote anymore
+	vd = VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "b"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+
+	// the new key votes in the next poll
+	pollHash = forTestCreatePoll(t, app, gov, electionID, map[string]string{"y": "yes", "n": "no"})
+	vd = VoteDeliveryData{From: otherHexs[0], PollHash: pollHash, Choice: "y"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, others[0], VOTE, &vd))
+}
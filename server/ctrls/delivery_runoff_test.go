@@ -0,0 +1,90 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+)
+
+func forTestRunoffPoll(t *testing.T, app *TVApplication, gov crypto.PrivKey, electionID string, endHeight int64) string {
+	pj := PollJson{Description: "mayor", Choices: map[string]string{"a": "alice", "b": "bob", "c": "carol"}, RunoffThreshold: 50}
+	pollHash := forTestUploadPollJson(t, pj)
+	pubB, _ := gov.GetPublic().Bytes()
+	pd := PollDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, PollHash: pollHash, EndHeight: endHeight}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, gov, POLL, &pd))
+	return pollHash
+}
+
+func forTestBlock(app *TVApplication, height int64) {
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: height}})
+}
+
+func TestRunoffPollFailWithoutEndHeight(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+
+	pj := PollJson{Description: "mayor", Choices: map[string]string{"a": "alice", "b": "bob", "c": "carol"}, RunoffThreshold: 50}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+}
+
+func TestRunoffNotNeeded(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	forTestBlock(app, 1)
+	pollHash := forTestRunoffPoll(t, app, gov, electionID, 3)
+
+	for i, c := range []string{"a", "a", "b"} {
+		forTestCreateVote(t, app, voters[i], electionID, pollHash, c)
+	}
+	forTestBlock(app, 3)
+	app.EndBlock(types.RequestEndBlock{Height: 3})
+	assert.False(t, app.state.HasPoll(RunoffPollHash(pollHash)))
+}
+
+func TestRunoffSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 5)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	forTestBlock(app, 1)
+	pollHash := forTestRunoffPoll(t, app, gov, electionID, 3)
+
+	for i, c := range []string{"a", "a", "b", "c"} {
+		forTestCreateVote(t, app, voters[i], electionID, pollHash, c)
+	}
+	forTestBlock(app, 3)
+	app.EndBlock(types.RequestEndBlock{Height: 3})
+
+	// the poll has ended
+	forTestBlock(app, 4)
+	vd := VoteDeliveryData{From: voterHexs[4], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[4], VOTE, &vd))
+
+	// the runoff is between the choice with the most votes, and the first of the choices with the same votes
+	runoff := RunoffPollHash(pollHash)
+	pvq, err := app.queryVotes(runoff)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"a": 0, "b": 0}, pvq.Choices)
+	assert.Equal(t, pollHash, pvq.RunoffOf)
+	assert.Equal(t, int64(5), pvq.EndHeight)
+	pvq, err = app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, runoff, pvq.Runoff)
+
+	vd = VoteDeliveryData{From: voterHexs[4], PollHash: runoff, Choice: "b"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[4], VOTE, &vd))
+	vd = VoteDeliveryData{From: voterHexs[3], PollHash: runoff, Choice: "c"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[3], VOTE, &vd))
+}
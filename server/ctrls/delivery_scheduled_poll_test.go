@@ -0,0 +1,121 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/mragiadakos/tendervoting/server/confs"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+)
+
+func forTestSchedulePoll(t *testing.T, app *TVApplication, gov crypto.PrivKey, pd PollDeliveryData) uint32 {
+	pubB, _ := gov.GetPublic().Bytes()
+	pd.From = hex.EncodeToString(pubB)
+	if len(pd.PollHash) == 0 {
+		pd.PollHash = forTestUploadPoll(t, map[string]string{"y": "yes", "n": "no"})
+	}
+	return forTestDeliver(t, app, gov, POLL, &pd)
+}
+
+func TestScheduledPollFailOnWrongActivation(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 10, Time: 1000}})
+
+	wrongs := []PollDeliveryData{
+		// the height has passed
+		{ActivationHeight: 10},
+		// the time has passed
+		{ActivationTime: 1000},
+		// both the height and the time
+		{ActivationHeight: 20, ActivationTime: 2000},
+		// the poll ends before the activation
+		{ActivationHeight: 20, EndHeight: 15},
+	}
+	for _, pd := range wrongs {
+		pd.ElectionID = electionID
+		assert.Equal(t, CodeTypeUnauthorized, forTestSchedulePoll(t, app, gov, pd))
+	}
+}
+
+func TestScheduledPollSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 10, Time: 1000}})
+
+	byHeight := forTestUploadPoll(t, map[string]string{"a": "alice", "b": "bob"})
+	assert.Equal(t, CodeTypeOK, forTestSchedulePoll(t, app, gov, PollDeliveryData{ElectionID: electionID, PollHash: byHeight, ActivationHeight: 12}))
+	// the same poll can not be scheduled twice
+	assert.Equal(t, CodeTypeUnauthorized, forTestSchedulePoll(t, app, gov, PollDeliveryData{ElectionID: electionID, PollHash: byHeight, ActivationHeight: 13}))
+	byTime := forTestUploadPoll(t, map[string]string{"y": "yes", "n": "no"})
+	assert.Equal(t, CodeTypeOK, forTestSchedulePoll(t, app, gov, PollDeliveryData{ElectionID: electionID, PollHash: byTime, ActivationTime: 2000}))
+
+	// the scheduled poll does not accept votes before the activation
+	assert.False(t, app.state.HasPoll(byHeight))
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: byHeight, Choice: "a"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 12, Time: 1500}})
+	assert.True(t, app.state.HasPoll(byHeight))
+	assert.False(t, app.state.HasPoll(byTime))
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 13, Time: 2000}})
+	assert.True(t, app.state.HasPoll(byTime))
+	assert.True(t, app.state.IsLatestPoll(byTime))
+
+	list := app.state.GetScheduledPolls()
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, SCHEDULED_ACTIVATED, list[0].Status)
+	assert.Equal(t, int64(12), list[0].ActivatedHeight)
+	assert.Equal(t, SCHEDULED_ACTIVATED, list[1].Status)
+}
+
+func TestScheduledPollDroppedOnClosedElection(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 10}})
+
+	pollHash := forTestUploadPoll(t, map[string]string{"a": "alice", "b": "bob"})
+	assert.Equal(t, CodeTypeOK, forTestSchedulePoll(t, app, gov, PollDeliveryData{ElectionID: electionID, PollHash: pollHash, ActivationHeight: 12}))
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_CLOSED))
+
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 12}})
+	assert.False(t, app.state.HasPoll(pollHash))
+	assert.Equal(t, SCHEDULED_DROPPED, app.state.GetScheduledPolls()[0].Status)
+}
+
+func TestScheduledPollActivatesWithoutIpfs(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 10}})
+
+	pollHash := forTestUploadPoll(t, map[string]string{"a": "alice", "b": "bob"})
+	assert.Equal(t, CodeTypeOK, forTestSchedulePoll(t, app, gov, PollDeliveryData{ElectionID: electionID, PollHash: pollHash, ActivationHeight: 12}))
+
+	// the activation uses the poll.json from the delivery, even when the IPFS is not reachable
+	ipfs := confs.Conf.IpfsConnection
+	confs.Conf.IpfsConnection = "127.0.0.1:1"
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 12}})
+	confs.Conf.IpfsConnection = ipfs
+
+	ps, err := app.state.GetPoll(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"a": 0, "b": 0}, ps.Choices)
+	assert.Equal(t, SCHEDULED_ACTIVATED, app.state.GetScheduledPolls()[0].Status)
+}
@@ -0,0 +1,89 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoterStatusWithoutElections(t *testing.T) {
+	app := NewTVApplication()
+	_, otherHexs := forTestVoters(t, 1)
+
+	vsq := app.queryVoterStatus(otherHexs[0])
+	assert.Equal(t, otherHexs[0], vsq.Voter)
+	assert.Equal(t, 0, len(vsq.Elections))
+}
+
+func TestVoterStatusSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice", "b": "bob"})
+
+	vsq := app.queryVoterStatus(voterHexs[0])
+	assert.Equal(t, 1, len(vsq.Elections))
+	assert.Equal(t, electionID, vsq.Elections[0].ID)
+	assert.Equal(t, []VoterPollQuery{{PollHash: pollHash}}, vsq.Elections[0].Polls)
+
+	forTestCreateVote(t, app, voters[0], electionID, pollHash, "a")
+	vsq = app.queryVoterStatus(voterHexs[0])
+	assert.True(t, vsq.Elections[0].Polls[0].Voted)
+	vsq = app.queryVoterStatus(voterHexs[1])
+	assert.False(t, vsq.Elections[0].Polls[0].Voted)
+
+	// only the latest poll is open for the votes
+	nextHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"y": "yes", "n": "no"})
+	vsq = app.queryVoterStatus(voterHexs[0])
+	assert.Equal(t, []VoterPollQuery{{PollHash: nextHash}}, vsq.Elections[0].Polls)
+}
+
+func TestVoterStatusFollowsReplacedKey(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	_, otherHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice", "b": "bob"})
+	forTestCreateVote(t, app, voters[0], electionID, pollHash, "a")
+
+	assert.Equal(t, CodeTypeOK, forTestReplaceKey(t, app, gov, electionID, voterHexs[0], otherHexs[0]))
+	vsq := app.queryVoterStatus(voterHexs[0])
+	assert.Equal(t, 0, len(vsq.Elections))
+	// the new key has the old key's vote
+	vsq = app.queryVoterStatus(otherHexs[0])
+	assert.Equal(t, 1, len(vsq.Elections))
+	assert.True(t, vsq.Elections[0].Polls[0].Voted)
+}
+
+func TestVoterStatusWithVotersRoot(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	_, otherHexs := forTestVoters(t, 1)
+	forTestCreateElection(t, app, gov, otherHexs)
+	electionID, mt := forTestCreateVotersRootElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice", "b": "bob"})
+
+	// the index has only the elections with the voters' root
+	assert.Equal(t, []string{electionID}, app.state.GetVotersRootElections())
+
+	// the election is listed for any key, because only the Merkle proof shows the voters
+	vsq := app.queryVoterStatus(voterHexs[1])
+	assert.Equal(t, 1, len(vsq.Elections))
+	assert.True(t, vsq.Elections[0].MembershipByProof)
+
+	proof, err := mt.Proof(voterHexs[0])
+	assert.Nil(t, err)
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "a", Proof: proof}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	vsq = app.queryVoterStatus(voterHexs[0])
+	assert.Equal(t, electionID, vsq.Elections[0].ID)
+	assert.True(t, vsq.Elections[0].Polls[0].Voted)
+}
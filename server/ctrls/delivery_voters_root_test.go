@@ -0,0 +1,120 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/mragiadakos/tendervoting/server/confs"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+)
+
+func forTestCreateVotersRootElection(t *testing.T, app *TVApplication, privk crypto.PrivKey, voters []string) (string, *MerkleTree) {
+	mt, err := NewMerkleTree(voters)
+	assert.Nil(t, err)
+	electionID := forTestDeliverElection(t, app, privk, ElectionDeliveryData{VotersRoot: mt.Root(), VotersCount: len(voters)})
+	return electionID, mt
+}
+
+func TestVotersRootElectionFailOnVotersAndRoot(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 3)
+	mt, err := NewMerkleTree(voterHexs)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	ed := ElectionDeliveryData{}
+	ed.ID = uuid.NewV4().String()
+	ed.From = hex.EncodeToString(pubB)
+	ed.Voters = voterHexs
+	ed.VotersRoot = mt.Root()
+	ed.VotersCount = len(voterHexs)
+	confs.Conf.GonvermentPublicKeyHex = ed.From
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, privk, ELECTION, &ed))
+}
+
+func TestVotersRootElectionShowsTheNumberOfVoters(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 5)
+	electionID, _ := forTestCreateVotersRootElection(t, app, privk, voterHexs)
+
+	qreq := types.RequestQuery{}
+	qreq.Path = "/elections/latest"
+	qresp := app.Query(qreq)
+	eq := ElectionQuery{}
+	json.Unmarshal(qresp.Value, &eq)
+	assert.Equal(t, electionID, eq.ID)
+	assert.Equal(t, 5, eq.NumberOfVoters)
+}
+
+func TestVotersRootVoteFailOnMissingProof(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 5)
+	electionID, _ := forTestCreateVotersRootElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	vd := VoteDeliveryData{From: voterHexs[2], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[2], VOTE, &vd))
+}
+
+func TestVotersRootVoteFailOnProofOfOtherVoter(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 5)
+	others, otherHexs := forTestVoters(t, 1)
+	electionID, mt := forTestCreateVotersRootElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	proof, err := mt.Proof(voterHexs[2])
+	assert.Nil(t, err)
+	vd := VoteDeliveryData{From: otherHexs[0], PollHash: pollHash, Choice: "a", Proof: proof}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, others[0], VOTE, &vd))
+}
+
+func TestVotersRootVoteSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 5)
+	electionID, mt := forTestCreateVotersRootElection(t, app, privk, voterHexs)
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	for i, v := range voterHexs {
+		proof, err := mt.Proof(v)
+		assert.Nil(t, err)
+		vd := VoteDeliveryData{From: v, PollHash: pollHash, Choice: "b", Proof: proof}
+		assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[i], VOTE, &vd))
+	}
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, pvq.Choices["b"])
+}
+
+func TestVotersRootVoteSuccessfulOnUppercaseRoot(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	mt, err := NewMerkleTree(voterHexs)
+	assert.Nil(t, err)
+	electionID := forTestDeliverElection(t, app, privk, ElectionDeliveryData{VotersRoot: strings.ToUpper(mt.Root()), VotersCount: len(voterHexs)})
+	pollHash := forTestCreatePoll(t, app, privk, electionID, map[string]string{"a": "a", "b": "b"})
+
+	proof, err := mt.Proof(voterHexs[0])
+	assert.Nil(t, err)
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Choice: "a", Proof: proof}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+}
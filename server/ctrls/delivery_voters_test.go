@@ -0,0 +1,108 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/tendermint/abci/types"
+)
+
+func forTestChangeVoters(t *testing.T, app *TVApplication, privk crypto.PrivKey, dt DeliveryType, electionID string, voters []string) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+	vd := VotersDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, Voters: voters}
+	return forTestDeliver(t, app, privk, dt, &vd)
+}
+
+func TestAddVotersFailOnNonGonverment(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs[:1])
+
+	code := forTestChangeVoters(t, app, voters[0], ADD_VOTERS, electionID, voterHexs[1:])
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestAddVotersFailOnExistingVoter(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs[:1])
+
+	code := forTestChangeVoters(t, app, gov, ADD_VOTERS, electionID, voterHexs)
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestRemoveVotersFailOnNonVoter(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs[:1])
+
+	code := forTestChangeVoters(t, app, gov, REMOVE_VOTERS, electionID, voterHexs[1:])
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestRemoveVotersFailOnVoting(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs)
+
+	code := forTestChangeVoters(t, app, gov, REMOVE_VOTERS, electionID, voterHexs[1:])
+	assert.Equal(t, CodeTypeOK, code)
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_OPEN))
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a"})
+
+	vd := VoteDeliveryData{From: voterHexs[1], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[1], VOTE, &vd))
+}
+
+func TestAddVotersFailOnOpenElection(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs[:1])
+
+	code := forTestChangeVoters(t, app, gov, ADD_VOTERS, electionID, voterHexs[1:])
+	assert.Equal(t, CodeTypeUnauthorized, code)
+}
+
+func TestAmendVotersSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 1}})
+	electionID := forTestCreateDraftElection(t, app, gov, voterHexs[:2])
+
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 2}})
+	assert.Equal(t, CodeTypeOK, forTestChangeVoters(t, app, gov, ADD_VOTERS, electionID, voterHexs[2:]))
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 3}})
+	assert.Equal(t, CodeTypeOK, forTestChangeVoters(t, app, gov, REMOVE_VOTERS, electionID, voterHexs[:1]))
+
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_OPEN))
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a"})
+	vd := VoteDeliveryData{From: voterHexs[2], PollHash: pollHash, Choice: "a"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[2], VOTE, &vd))
+
+	eaq, err := app.queryElectionAmendments(electionID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{voterHexs[1], voterHexs[2]}, eaq.Voters)
+	assert.Equal(t, 2, len(eaq.Amendments))
+	assert.Equal(t, int64(2), eaq.Amendments[0].Height)
+	assert.Equal(t, voterHexs[2:], eaq.Amendments[0].Added)
+	assert.Equal(t, int64(3), eaq.Amendments[1].Height)
+	assert.Equal(t, voterHexs[:1], eaq.Amendments[1].Removed)
+
+	list := app.queryListElections()
+	assert.Equal(t, 2, list[0].NumberOfVoters)
+}
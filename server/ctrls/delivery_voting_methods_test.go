@@ -0,0 +1,267 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestMethodPoll(t *testing.T, app *TVApplication, gov crypto.PrivKey, electionID string, method VotingMethod) string {
+	pj := PollJson{Description: "mayor", Choices: map[string]string{"a": "alice", "b": "bob", "c": "carol"}, Method: method}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	return pollHash
+}
+
+func TestSchulzeRanking(t *testing.T) {
+	// the example from the Schulze method's article, with the winner E
+	ballots := map[string]int{"ACBED": 5, "ADECB": 5, "BEDAC": 8, "CABED": 3, "CAEBD": 7, "CBADE": 2, "DCEBA": 7, "EBADC": 8}
+	pairwise := NewPairwise(map[string]int{"A": 0, "B": 0, "C": 0, "D": 0, "E": 0})
+	for b, n := range ballots {
+		for i := 0; i < n; i++ {
+			AddRankingToPairwise(pairwise, strings.Split(b, ""))
+		}
+	}
+	assert.Equal(t, 20, pairwise["A"]["B"])
+	assert.Equal(t, []string{"E", "A", "C", "B", "D"}, SchulzeRanking(pairwise))
+}
+
+func TestMethodPollFailOnWrongMethod(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+
+	wrongs := []PollJson{
+		{Description: "mayor", Choices: map[string]string{"a": "alice"}, Method: "borda"},
+		{Description: "mayor", Choices: map[string]string{"a": "alice"}, Method: METHOD_SCHULZE, WriteIns: true},
+		{Description: "mayor", Choices: map[string]string{"a": "alice"}, Method: METHOD_SCHULZE, ScoreMax: 3},
+		{Description: "mayor", Choices: map[string]string{"a": "alice"}, Method: METHOD_SCORE, ScoreMax: 101},
+	}
+	for _, pj := range wrongs {
+		pollHash := forTestUploadPollJson(t, pj)
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	}
+}
+
+func TestSchulzeVoteFailOnWrongRanking(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestMethodPoll(t, app, gov, electionID, METHOD_SCHULZE)
+
+	wrongs := []VoteDeliveryData{
+		// the choice instead of the ranking
+		{Choice: "a"},
+		// not all the choices
+		{Ranking: []string{"a", "b"}},
+		// the same choice twice
+		{Ranking: []string{"a", "b", "a"}},
+		// a choice that does not exist
+		{Ranking: []string{"a", "b", "d"}},
+		// the scores instead of the ranking
+		{Scores: map[string]int{"a": 1, "b": 2, "c": 3}},
+	}
+	for _, vd := range wrongs {
+		vd.From = voterHexs[0]
+		vd.PollHash = pollHash
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	}
+}
+
+func TestSchulzeVoteSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestMethodPoll(t, app, gov, electionID, METHOD_SCHULZE)
+
+	rankings := [][]string{{"a", "b", "c"}, {"b", "c", "a"}, {"c", "b", "a"}}
+	for i, r := range rankings {
+		vd := VoteDeliveryData{From: voterHexs[i], PollHash: pollHash, Ranking: r}
+		assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[i], VOTE, &vd))
+	}
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, METHOD_SCHULZE, pvq.Method)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1}, pvq.Choices)
+	assert.Equal(t, 2, pvq.Pairwise["b"]["a"])
+	assert.Equal(t, 2, pvq.Pairwise["b"]["c"])
+	assert.Equal(t, []string{"b", "c", "a"}, pvq.Ranking)
+}
+
+func TestScoreVoteFailOnWrongScores(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestMethodPoll(t, app, gov, electionID, METHOD_SCORE)
+
+	wrongs := []VoteDeliveryData{
+		// not all the choices
+		{Scores: map[string]int{"a": 1, "b": 2}},
+		// bigger than the max
+		{Scores: map[string]int{"a": 1, "b": 2, "c": 6}},
+		// negative
+		{Scores: map[string]int{"a": 1, "b": 2, "c": -1}},
+		// the ranking instead of the scores
+		{Ranking: []string{"a", "b", "c"}},
+	}
+	for _, vd := range wrongs {
+		vd.From = voterHexs[0]
+		vd.PollHash = pollHash
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	}
+}
+
+func TestScoreVoteSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestMethodPoll(t, app, gov, electionID, METHOD_SCORE)
+
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, Scores: map[string]int{"a": 5, "b": 0, "c": 2}}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	vd = VoteDeliveryData{From: voterHexs[1], PollHash: pollHash, Scores: map[string]int{"a": 2, "b": 1, "c": 2}}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[1], VOTE, &vd))
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, METHOD_SCORE, pvq.Method)
+	assert.Equal(t, map[string]float64{"a": 3.5, "b": 0.5, "c": 2}, pvq.AverageScores)
+}
+
+func TestQuadraticVoteFailOnOverBudget(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pj := PollJson{Description: "budget", Choices: map[string]string{"a": "parks", "b": "roads"}, Method: METHOD_QUADRATIC, VoiceCredits: 25}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+
+	wrongs := []VoteDeliveryData{
+		// 4*4 + 3*3 = 25 credits are ok, but 4*4 + 4*4 = 32 are not
+		{QuadraticVotes: map[string]int{"a": 4, "b": 4}},
+		{QuadraticVotes: map[string]int{"a": 6}},
+		{QuadraticVotes: map[string]int{"a": -1}},
+		{QuadraticVotes: map[string]int{"c": 1}},
+		{QuadraticVotes: map[string]int{"a": 1}, Choice: "a"},
+	}
+	for _, vd := range wrongs {
+		vd.From = voterHexs[0]
+		vd.PollHash = pollHash
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	}
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, QuadraticVotes: map[string]int{"a": 4, "b": 3}}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+}
+
+func TestQuadraticVoteSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestMethodPoll(t, app, gov, electionID, METHOD_QUADRATIC)
+
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, QuadraticVotes: map[string]int{"a": 10}}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	vd = VoteDeliveryData{From: voterHexs[1], PollHash: pollHash, QuadraticVotes: map[string]int{"a": 1, "b": 5, "c": 7}}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[1], VOTE, &vd))
+
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, METHOD_QUADRATIC, pvq.Method)
+	assert.Equal(t, map[string]int{"a": 11, "b": 5, "c": 7}, pvq.Choices)
+}
+
+func forTestBudgetingPoll(t *testing.T, app *TVApplication, gov crypto.PrivKey, electionID string) string {
+	pj := PollJson{
+		Description: "the community's projects",
+		Choices:     map[string]string{"a": "park", "b": "library", "c": "road", "d": "bench"},
+		Method:      METHOD_BUDGETING,
+		Costs:       map[string]int{"a": 60, "b": 50, "c": 30, "d": 5},
+		Budget:      100,
+	}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	return pollHash
+}
+
+func TestBudgetingPollFailOnWrongCosts(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	_, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+
+	choices := map[string]string{"a": "park", "b": "library"}
+	wrongs := []PollJson{
+		// a choice without cost
+		{Description: "projects", Choices: choices, Method: METHOD_BUDGETING, Costs: map[string]int{"a": 10}, Budget: 100},
+		// zero cost
+		{Description: "projects", Choices: choices, Method: METHOD_BUDGETING, Costs: map[string]int{"a": 10, "b": 0}, Budget: 100},
+		// without budget
+		{Description: "projects", Choices: choices, Method: METHOD_BUDGETING, Costs: map[string]int{"a": 10, "b": 20}},
+		// the costs without the budgeting method
+		{Description: "projects", Choices: choices, Costs: map[string]int{"a": 10, "b": 20}, Budget: 100},
+	}
+	for _, pj := range wrongs {
+		pollHash := forTestUploadPollJson(t, pj)
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	}
+}
+
+func TestBudgetingVoteFailOnOverBudget(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestBudgetingPoll(t, app, gov, electionID)
+
+	wrongs := []VoteDeliveryData{
+		// 60 + 50 is more than 100
+		{Projects: []string{"a", "b"}},
+		{Projects: []string{"c", "c"}},
+		{Projects: []string{"e"}},
+		{Choice: "a"},
+	}
+	for _, vd := range wrongs {
+		vd.From = voterHexs[0]
+		vd.PollHash = pollHash
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	}
+}
+
+func TestBudgetingVoteSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 3)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestBudgetingPoll(t, app, gov, electionID)
+
+	projects := [][]string{{"a", "c"}, {"b", "c", "d"}, {"a", "d"}}
+	for i, p := range projects {
+		vd := VoteDeliveryData{From: voterHexs[i], PollHash: pollHash, Projects: p}
+		assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[i], VOTE, &vd))
+	}
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"a": 2, "b": 1, "c": 2, "d": 2}, pvq.Choices)
+	// the library does not fit after the park, the road and the bench
+	assert.Equal(t, []string{"a", "c", "d"}, pvq.Funded)
+}
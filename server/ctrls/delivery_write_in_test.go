@@ -0,0 +1,147 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func forTestWriteInPoll(t *testing.T, app *TVApplication, gov crypto.PrivKey, electionID string) string {
+	pj := PollJson{Description: "mayor", Choices: map[string]string{"a": "alice", "b": "bob"}, WriteIns: true}
+	pollHash := forTestUploadPollJson(t, pj)
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, gov, electionID, pollHash))
+	return pollHash
+}
+
+func forTestMergeWriteIns(t *testing.T, app *TVApplication, privk crypto.PrivKey, pollHash, candidate string, variants ...string) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+	md := MergeWriteInsDeliveryData{From: hex.EncodeToString(pubB), PollHash: pollHash, Variants: variants, Candidate: candidate}
+	return forTestDeliver(t, app, privk, MERGE_WRITE_INS, &md)
+}
+
+func TestNormalizeWriteIn(t *testing.T) {
+	assert.Equal(t, "john smith", NormalizeWriteIn("  John \t SMITH "))
+	assert.Nil(t, ValidateWriteIn("john smith"))
+	assert.NotNil(t, ValidateWriteIn("John Smith"))
+	assert.NotNil(t, ValidateWriteIn(""))
+	assert.NotNil(t, ValidateWriteIn(strings.Repeat("a", writeInMaxSize+1)))
+}
+
+func TestWriteInVoteFailOnPollWithoutWriteIns(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "alice"})
+
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, WriteIn: "john smith"}
+	assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+}
+
+func TestWriteInVoteFailOnWrongWriteIn(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestWriteInPoll(t, app, gov, electionID)
+
+	wrongs := []VoteDeliveryData{
+		// not normalized
+		{WriteIn: "John Smith"},
+		// too big
+		{WriteIn: strings.Repeat("a", writeInMaxSize+1)},
+		// the write-in with the choice
+		{WriteIn: "john smith", Choice: "a"},
+	}
+	for _, vd := range wrongs {
+		vd.From = voterHexs[0]
+		vd.PollHash = pollHash
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	}
+}
+
+func TestWriteInMergeFailOnOpenPoll(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestWriteInPoll(t, app, gov, electionID)
+
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, WriteIn: "j. smith"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	assert.Equal(t, CodeTypeUnauthorized, forTestMergeWriteIns(t, app, gov, pollHash, "john smith", "j. smith"))
+}
+
+func TestWriteInMergeFailOnNotGonverment(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestWriteInPoll(t, app, gov, electionID)
+
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, WriteIn: "j. smith"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_CLOSED))
+	assert.Equal(t, CodeTypeUnauthorized, forTestMergeWriteIns(t, app, voters[0], pollHash, "john smith", "j. smith"))
+}
+
+func TestWriteInSuccessful(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 4)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestWriteInPoll(t, app, gov, electionID)
+
+	writeIns := []string{"john smith", "j. smith", "john smith", "carol"}
+	for i, w := range writeIns {
+		vd := VoteDeliveryData{From: voterHexs[i], PollHash: pollHash, WriteIn: w}
+		assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[i], VOTE, &vd))
+	}
+	pvq, err := app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"john smith": 2, "j. smith": 1, "carol": 1}, pvq.WriteIns)
+
+	assert.Equal(t, CodeTypeOK, forTestChangeElectionStatus(t, app, gov, electionID, ELECTION_CLOSED))
+	// a write-in that does not exist
+	assert.Equal(t, CodeTypeUnauthorized, forTestMergeWriteIns(t, app, gov, pollHash, "john smith", "jon smith"))
+	assert.Equal(t, CodeTypeOK, forTestMergeWriteIns(t, app, gov, pollHash, "john smith", "j. smith"))
+	// the write-in can become one of the poll's choices
+	assert.Equal(t, CodeTypeOK, forTestMergeWriteIns(t, app, gov, pollHash, "a", "carol"))
+
+	pvq, err = app.queryVotes(pollHash)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"john smith": 3}, pvq.WriteIns)
+	assert.Equal(t, 1, pvq.Choices["a"])
+	assert.Equal(t, 2, len(pvq.WriteInMerges))
+}
+
+func TestWriteInMergeSuccessfulAfterPollEnds(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 1)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	forTestBlock(app, 1)
+	pj := PollJson{Description: "mayor", Choices: map[string]string{"a": "alice", "b": "bob"}, WriteIns: true}
+	pollHash := forTestUploadPollJson(t, pj)
+	pubB, _ := gov.GetPublic().Bytes()
+	pd := PollDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, PollHash: pollHash, EndHeight: 5}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, gov, POLL, &pd))
+
+	vd := VoteDeliveryData{From: voterHexs[0], PollHash: pollHash, WriteIn: "j. smith"}
+	assert.Equal(t, CodeTypeOK, forTestDeliver(t, app, voters[0], VOTE, &vd))
+	assert.Equal(t, CodeTypeUnauthorized, forTestMergeWriteIns(t, app, gov, pollHash, "john smith", "j. smith"))
+
+	// the poll is still the latest of the open election, but it has ended
+	forTestBlock(app, 6)
+	assert.Equal(t, CodeTypeOK, forTestMergeWriteIns(t, app, gov, pollHash, "john smith", "j. smith"))
+}
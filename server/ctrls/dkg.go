@@ -0,0 +1,362 @@
+package ctrls
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// The election's key is generated by the trustees without a dealer, like the joint Feldman protocol.
+// Each trustee deals a random polynomial with the commitments on its coefficients,
+// and sends to every trustee the share of the polynomial encrypted with the trustee's exchange key.
+// The election's key is the product of the dealings' keys, and each trustee's share is the sum
+// of the shares that it received, so no trustee knows the secret of the election's key.
+
+// ExchangeKey is the trustee's key for the shares of the dealings, and it should never leave the trustee's machine
+type ExchangeKey struct {
+	Secret    string
+	PublicKey string
+}
+
+func NewExchangeKey() (*ExchangeKey, error) {
+	e, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	return &ExchangeKey{Secret: bigToHex(e), PublicKey: bigToHex(groupExp(groupG, e))}, nil
+}
+
+// EncryptedShare is the share f(index) + H(E^r) with the ephemeral g^r, so only the owner of E can open it
+type EncryptedShare struct {
+	Ephemeral string
+	Share     string
+}
+
+type TrusteeDealing struct {
+	// the commitments g^a on the coefficients of the trustee's polynomial
+	Commitments []string
+	// the proof that the trustee knows the secret of the first commitment,
+	// so the trustee can not choose its key from the keys of the other dealings
+	KeyProof EqualityProof
+	// the encrypted shares by the index of the trustee that receives each one
+	Shares       map[int]EncryptedShare
+	Height       int64
+	Disqualified bool `json:",omitempty"`
+}
+
+// shareMask is the hash of the exchange's shared key, that masks the share
+func shareMask(key *big.Int) *big.Int {
+	return hashToScalar(key)
+}
+
+// NewTrusteeDealing creates the trustee's random polynomial and encrypts its shares for all the trustees.
+// The polynomial is not kept, because the trustee receives its own share like the others.
+func NewTrusteeDealing(ee *ElectionEncryption) (*TrusteeDealing, error) {
+	coefficients := []*big.Int{}
+	td := &TrusteeDealing{Shares: map[int]EncryptedShare{}}
+	for i := 0; i < ee.Threshold; i++ {
+		a, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		coefficients = append(coefficients, a)
+		td.Commitments = append(td.Commitments, bigToHex(groupExp(groupG, a)))
+	}
+	c0 := groupExp(groupG, coefficients[0])
+	proof, err := proveEquality(coefficients[0], groupG, groupG, c0, c0)
+	if err != nil {
+		return nil, err
+	}
+	td.KeyProof = proof
+	for _, v := range ee.Trustees {
+		e, err := groupElement(v.ExchangeKey)
+		if err != nil {
+			return nil, err
+		}
+		r, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		share := evaluatePolynomial(coefficients, int64(v.Index))
+		share.Add(share, shareMask(groupExp(e, r)))
+		share.Mod(share, groupQ)
+		td.Shares[v.Index] = EncryptedShare{Ephemeral: bigToHex(groupExp(groupG, r)), Share: bigToHex(share)}
+	}
+	return td, nil
+}
+
+func commitmentElements(commitments []string) ([]*big.Int, error) {
+	elements := []*big.Int{}
+	for _, v := range commitments {
+		c, err := groupElement(v)
+		if err != nil {
+			return nil, errors.New("The dealing's commitment is not correct: " + err.Error())
+		}
+		elements = append(elements, c)
+	}
+	return elements, nil
+}
+
+// ValidateDealing checks the commitments, the proof of the key and that every trustee has a share
+func (ee *ElectionEncryption) ValidateDealing(td TrusteeDealing) error {
+	if len(td.Commitments) != ee.Threshold {
+		return errors.New("The dealing should have one commitment for each coefficient.")
+	}
+	commitments, err := commitmentElements(td.Commitments)
+	if err != nil {
+		return err
+	}
+	err = verifyEquality(td.KeyProof, groupG, groupG, commitments[0], commitments[0])
+	if err != nil {
+		return errors.New("The dealing's proof of the key is not correct: " + err.Error())
+	}
+	if len(td.Shares) != len(ee.Trustees) {
+		return errors.New("The dealing should have one share for each trustee.")
+	}
+	for _, v := range ee.Trustees {
+		es, ok := td.Shares[v.Index]
+		if !ok {
+			return errors.New("The dealing does not have a share for the trustee " + v.PublicKey + ".")
+		}
+		_, err = groupElement(es.Ephemeral)
+		if err != nil {
+			return errors.New("The share for the trustee " + v.PublicKey + " is not correct: " + err.Error())
+		}
+		_, err = scalar(es.Share)
+		if err != nil {
+			return errors.New("The share for the trustee " + v.PublicKey + " is not correct: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// verifyShare checks that g^share is the commitments' value at the index
+func verifyShare(commitments []string, index int, share *big.Int) error {
+	elements, err := commitmentElements(commitments)
+	if err != nil {
+		return err
+	}
+	if evaluateCommitments(elements, index).Cmp(groupExp(groupG, share)) != 0 {
+		return errors.New("The share does not match the dealing's commitments.")
+	}
+	return nil
+}
+
+func unmaskShare(es EncryptedShare, key *big.Int) (*big.Int, error) {
+	masked, err := scalar(es.Share)
+	if err != nil {
+		return nil, err
+	}
+	share := new(big.Int).Sub(masked, shareMask(key))
+	return share.Mod(share, groupQ), nil
+}
+
+// sharedKey returns R^e for the ephemeral R of the share
+func (ek *ExchangeKey) sharedKey(es EncryptedShare) (*big.Int, *big.Int, *big.Int, error) {
+	e, err := scalar(ek.Secret)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	r, err := groupElement(es.Ephemeral)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return groupExp(r, e), r, e, nil
+}
+
+func (ek *ExchangeKey) OpenShare(es EncryptedShare) (*big.Int, error) {
+	key, _, _, err := ek.sharedKey(es)
+	if err != nil {
+		return nil, err
+	}
+	return unmaskShare(es, key)
+}
+
+// ProveShareKey reveals the shared key of the share with the proof that it is R^e,
+// so the chain can open the share of a complaint
+func (ek *ExchangeKey) ProveShareKey(es EncryptedShare) (string, EqualityProof, error) {
+	key, r, e, err := ek.sharedKey(es)
+	if err != nil {
+		return "", EqualityProof{}, err
+	}
+	proof, err := proveEquality(e, groupG, r, groupExp(groupG, e), key)
+	if err != nil {
+		return "", EqualityProof{}, err
+	}
+	return bigToHex(key), proof, nil
+}
+
+// VerifyComplaint opens the dealer's share for the complainer with the revealed key,
+// and the complaint is correct only when the share does not match the dealer's commitments
+func (ee *ElectionEncryption) VerifyComplaint(complainer, dealer ElectionTrustee, key string, proof EqualityProof) error {
+	if dealer.Dealing == nil {
+		return errors.New("The trustee " + dealer.PublicKey + " has not dealt.")
+	}
+	es, ok := dealer.Dealing.Shares[complainer.Index]
+	if !ok {
+		return errors.New("The dealing does not have a share for the trustee " + complainer.PublicKey + ".")
+	}
+	r, err := groupElement(es.Ephemeral)
+	if err != nil {
+		return err
+	}
+	e, err := groupElement(complainer.ExchangeKey)
+	if err != nil {
+		return err
+	}
+	k, err := groupElement(key)
+	if err != nil {
+		return err
+	}
+	err = verifyEquality(proof, groupG, r, e, k)
+	if err != nil {
+		return errors.New("The complaint's key is not correct: " + err.Error())
+	}
+	share, err := unmaskShare(es, k)
+	if err != nil {
+		return err
+	}
+	if verifyShare(dealer.Dealing.Commitments, complainer.Index, share) == nil {
+		return errors.New("The share from the trustee " + dealer.PublicKey + " is correct.")
+	}
+	return nil
+}
+
+func (ee *ElectionEncryption) IsKeyFinished() bool {
+	return len(ee.PublicKey) > 0
+}
+
+// qualifiedDealings returns the dealings of the trustees that are not disqualified
+func (ee *ElectionEncryption) qualifiedDealings() []*TrusteeDealing {
+	list := []*TrusteeDealing{}
+	for _, v := range ee.Trustees {
+		if v.Dealing != nil && !v.Dealing.Disqualified {
+			list = append(list, v.Dealing)
+		}
+	}
+	return list
+}
+
+// KeyComplaintBlocks is the number of blocks after the last dealing, that the trustees can complain about their shares
+const KeyComplaintBlocks = 10
+
+func (ee *ElectionEncryption) HasAllDealings() bool {
+	for _, v := range ee.Trustees {
+		if v.Dealing == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ComplaintDeadline returns the last height for the complaints, which counts from the last dealing
+func (ee *ElectionEncryption) ComplaintDeadline() int64 {
+	last := int64(0)
+	for _, v := range ee.Trustees {
+		if v.Dealing != nil && v.Dealing.Height > last {
+			last = v.Dealing.Height
+		}
+	}
+	return last + KeyComplaintBlocks
+}
+
+// ValidateComplaintPeriod checks that the complaints are accepted at the height,
+// which is before all the trustees have dealt, or until the deadline after the last dealing
+func (ee *ElectionEncryption) ValidateComplaintPeriod(height int64) error {
+	if ee.HasAllDealings() && height > ee.ComplaintDeadline() {
+		return errors.New("The complaints for the election's key ended at height " + strconv.FormatInt(ee.ComplaintDeadline(), 10) + ".")
+	}
+	return nil
+}
+
+// ValidateKeyGeneration checks that all the trustees have dealt, that the complaints' period has passed
+// and that a dealing is qualified
+func (ee *ElectionEncryption) ValidateKeyGeneration(height int64) error {
+	if ee.IsKeyFinished() {
+		return nil
+	}
+	for _, v := range ee.Trustees {
+		if v.Dealing == nil {
+			return errors.New("The trustee " + v.PublicKey + " has not dealt the election's key yet.")
+		}
+	}
+	if height <= ee.ComplaintDeadline() {
+		return errors.New("The trustees can complain about their shares until height " + strconv.FormatInt(ee.ComplaintDeadline(), 10) + ".")
+	}
+	if len(ee.qualifiedDealings()) == 0 {
+		return errors.New("All the trustees' dealings are disqualified.")
+	}
+	return nil
+}
+
+// FinishKeyGeneration creates the election's key and the trustees' verification keys from the qualified dealings
+func (ee *ElectionEncryption) FinishKeyGeneration(height int64) error {
+	err := ee.ValidateKeyGeneration(height)
+	if err != nil {
+		return err
+	}
+	h := big.NewInt(1)
+	vks := make([]*big.Int, len(ee.Trustees))
+	for i := range vks {
+		vks[i] = big.NewInt(1)
+	}
+	for _, d := range ee.qualifiedDealings() {
+		commitments, err := commitmentElements(d.Commitments)
+		if err != nil {
+			return err
+		}
+		h = groupMul(h, commitments[0])
+		for i, v := range ee.Trustees {
+			vks[i] = groupMul(vks[i], evaluateCommitments(commitments, v.Index))
+		}
+	}
+	ee.PublicKey = bigToHex(h)
+	for i := range ee.Trustees {
+		ee.Trustees[i].VerificationKey = bigToHex(vks[i])
+	}
+	return nil
+}
+
+// OpenShares returns the trustee's share from the qualified dealings,
+// and the trustees that dealt a share that does not match their commitments
+func (ee *ElectionEncryption) OpenShares(ek *ExchangeKey, index int) (*TrusteeShare, []string, error) {
+	sum := big.NewInt(0)
+	wrong := []string{}
+	for _, v := range ee.Trustees {
+		if v.Dealing == nil || v.Dealing.Disqualified {
+			continue
+		}
+		es, ok := v.Dealing.Shares[index]
+		if !ok {
+			wrong = append(wrong, v.PublicKey)
+			continue
+		}
+		share, err := ek.OpenShare(es)
+		if err != nil {
+			return nil, nil, err
+		}
+		if verifyShare(v.Dealing.Commitments, index, share) != nil {
+			wrong = append(wrong, v.PublicKey)
+			continue
+		}
+		sum.Add(sum, share)
+		sum.Mod(sum, groupQ)
+	}
+	return &TrusteeShare{Index: index, Share: bigToHex(sum)}, wrong, nil
+}
+
+// VerifyTrusteeShare checks the trustee's share with its verification key from the finished election's key
+func VerifyTrusteeShare(t ElectionTrustee, ts TrusteeShare) error {
+	share, err := scalar(ts.Share)
+	if err != nil {
+		return err
+	}
+	vk, err := groupElement(t.VerificationKey)
+	if err != nil {
+		return err
+	}
+	if groupExp(groupG, share).Cmp(vk) != 0 {
+		return errors.New("The share does not match the trustee's verification key.")
+	}
+	return nil
+}
@@ -0,0 +1,590 @@
+package ctrls
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The group is the 2048-bit MODP group from RFC 3526.
+// The p is a safe prime, so the generator 2 creates the subgroup of prime order q = (p-1)/2.
+var (
+	groupP = mustHexToBig("" +
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1" +
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD" +
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245" +
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D" +
+		"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F" +
+		"83655D23DCA3AD961C62F356208552BB9ED529077096966D" +
+		"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9" +
+		"DE2BCBF6955817183995497CEA956AE515D2261898FA0510" +
+		"15728E5A8AACAA68FFFFFFFFFFFFFFFF")
+	groupQ = new(big.Int).Rsh(groupP, 1)
+	groupG = big.NewInt(2)
+)
+
+func mustHexToBig(s string) *big.Int {
+	n, err := hexToBig(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func hexToBig(s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return nil, errors.New("The number " + s + " is not a correct hex.")
+	}
+	return n, nil
+}
+
+func bigToHex(n *big.Int) string {
+	return n.Text(16)
+}
+
+func randomScalar() (*big.Int, error) {
+	return rand.Int(rand.Reader, groupQ)
+}
+
+func groupExp(base, exp *big.Int) *big.Int {
+	return new(big.Int).Exp(base, exp, groupP)
+}
+
+func groupMul(a, b *big.Int) *big.Int {
+	n := new(big.Int).Mul(a, b)
+	return n.Mod(n, groupP)
+}
+
+func groupDiv(a, b *big.Int) *big.Int {
+	return groupMul(a, new(big.Int).ModInverse(b, groupP))
+}
+
+// groupElement decodes the hex and checks that it belongs to the subgroup of order q
+func groupElement(s string) (*big.Int, error) {
+	n, err := hexToBig(s)
+	if err != nil {
+		return nil, err
+	}
+	if n.Sign() <= 0 || n.Cmp(groupP) >= 0 {
+		return nil, errors.New("The number " + s + " is out of the group's range.")
+	}
+	if groupExp(n, groupQ).Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("The number " + s + " is not an element of the group.")
+	}
+	return n, nil
+}
+
+func scalar(s string) (*big.Int, error) {
+	n, err := hexToBig(s)
+	if err != nil {
+		return nil, err
+	}
+	if n.Sign() < 0 || n.Cmp(groupQ) >= 0 {
+		return nil, errors.New("The number " + s + " is out of the scalar's range.")
+	}
+	return n, nil
+}
+
+// hashToScalar is the Fiat-Shamir challenge for the non-interactive proofs
+func hashToScalar(parts ...*big.Int) *big.Int {
+	hexes := []string{}
+	for _, v := range parts {
+		hexes = append(hexes, bigToHex(v))
+	}
+	h := sha256.Sum256([]byte(strings.Join(hexes, ":")))
+	n := new(big.Int).SetBytes(h[:])
+	return n.Mod(n, groupQ)
+}
+
+// Ciphertext is an exponential ElGamal encryption (g^r, g^m * h^r) in hex
+type Ciphertext struct {
+	Alpha string
+	Beta  string
+}
+
+func EmptyCiphertext() Ciphertext {
+	return Ciphertext{Alpha: "1", Beta: "1"}
+}
+
+func (c Ciphertext) elements() (*big.Int, *big.Int, error) {
+	alpha, err := groupElement(c.Alpha)
+	if err != nil {
+		return nil, nil, err
+	}
+	beta, err := groupElement(c.Beta)
+	if err != nil {
+		return nil, nil, err
+	}
+	return alpha, beta, nil
+}
+
+// Add multiplies the ciphertexts, so the plaintexts are summed
+func (c Ciphertext) Add(o Ciphertext) (Ciphertext, error) {
+	a1, b1, err := c.elements()
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	a2, b2, err := o.elements()
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	return Ciphertext{Alpha: bigToHex(groupMul(a1, a2)), Beta: bigToHex(groupMul(b1, b2))}, nil
+}
+
+type ElectionTrustee struct {
+	PublicKey       string          // the trustee's public key that signs the dealing and the decryptions
+	Index           int             // the x coordinate of the trustee's share
+	ExchangeKey     string          // g^e, that the other trustees encrypt the trustee's shares with
+	VerificationKey string          // g^share, when the election's key is finished
+	Dealing         *TrusteeDealing `json:",omitempty"`
+}
+
+// ElectionEncryption has not a dealer, because each trustee deals its own polynomial,
+// and the public key is the product of the qualified dealings' keys
+type ElectionEncryption struct {
+	PublicKey string // h = g^x, when the election's key is finished
+	Threshold int    // the number of trustees needed for the decryption
+	Trustees  []ElectionTrustee
+}
+
+// TrusteeShare is the secret share of a trustee and it should never leave the trustee's machine
+type TrusteeShare struct {
+	Index int
+	Share string
+}
+
+// NewElectionEncryption gives the indexes to the trustees, with the exchange key of each one,
+// and the trustees deal the election's key after the election is created
+func NewElectionEncryption(trustees, exchangeKeys []string, threshold int) (*ElectionEncryption, error) {
+	if len(trustees) != len(exchangeKeys) {
+		return nil, errors.New("Each trustee should have an exchange key.")
+	}
+	if threshold < 1 || threshold > len(trustees) {
+		return nil, errors.New("The threshold should be between 1 and the number of trustees.")
+	}
+	ee := &ElectionEncryption{Threshold: threshold}
+	for i, v := range trustees {
+		ee.Trustees = append(ee.Trustees, ElectionTrustee{PublicKey: v, Index: i + 1, ExchangeKey: exchangeKeys[i]})
+	}
+	return ee, nil
+}
+
+func evaluatePolynomial(coefficients []*big.Int, x int64) *big.Int {
+	res := big.NewInt(0)
+	bx := big.NewInt(x)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		res.Mul(res, bx)
+		res.Add(res, coefficients[i])
+		res.Mod(res, groupQ)
+	}
+	return res
+}
+
+// evaluateCommitments returns g^f(index) from the commitments on the polynomial's coefficients
+func evaluateCommitments(commitments []*big.Int, index int) *big.Int {
+	res := big.NewInt(1)
+	power := big.NewInt(1)
+	bIndex := big.NewInt(int64(index))
+	for _, c := range commitments {
+		res = groupMul(res, groupExp(c, power))
+		power = new(big.Int).Mul(power, bIndex)
+		power.Mod(power, groupQ)
+	}
+	return res
+}
+
+// Validate checks the encryption from the gonverment, that has only the trustees,
+// so the gonverment can not know the election's key
+func (ee *ElectionEncryption) Validate() error {
+	if len(ee.Trustees) == 0 {
+		return errors.New("The encryption has not any trustee.")
+	}
+	if ee.Threshold < 1 || ee.Threshold > len(ee.Trustees) {
+		return errors.New("The encryption's threshold should be between 1 and the number of trustees.")
+	}
+	if len(ee.PublicKey) > 0 {
+		return errors.New("The encryption's public key is created by the trustees' dealings.")
+	}
+	indexes := map[int]bool{}
+	keys := map[string]bool{}
+	for _, v := range ee.Trustees {
+		err := validatePublicKey(v.PublicKey)
+		if err != nil {
+			return errors.New("The trustee " + v.PublicKey + " has not a correct public key: " + err.Error())
+		}
+		if v.Index < 1 || indexes[v.Index] || keys[v.PublicKey] {
+			return errors.New("The trustee " + v.PublicKey + " is not unique or has not a correct index.")
+		}
+		indexes[v.Index] = true
+		keys[v.PublicKey] = true
+
+		_, err = groupElement(v.ExchangeKey)
+		if err != nil {
+			return errors.New("The trustee " + v.PublicKey + " has not a correct exchange key: " + err.Error())
+		}
+		if len(v.VerificationKey) > 0 || v.Dealing != nil {
+			return errors.New("The trustee " + v.PublicKey + " should deal the election's key after the election is created.")
+		}
+	}
+	return nil
+}
+
+func (ee *ElectionEncryption) GetTrustee(pubHex string) (*ElectionTrustee, bool) {
+	for _, v := range ee.Trustees {
+		if v.PublicKey == pubHex {
+			t := v
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// Encrypt returns the ciphertext of the message with the randomness that used,
+// because the randomness is needed for the ballot's proofs
+func (ee *ElectionEncryption) Encrypt(m int64) (Ciphertext, *big.Int, error) {
+	h, err := groupElement(ee.PublicKey)
+	if err != nil {
+		return Ciphertext{}, nil, err
+	}
+	r, err := randomScalar()
+	if err != nil {
+		return Ciphertext{}, nil, err
+	}
+	alpha := groupExp(groupG, r)
+	beta := groupMul(groupExp(groupG, big.NewInt(m)), groupExp(h, r))
+	return Ciphertext{Alpha: bigToHex(alpha), Beta: bigToHex(beta)}, r, nil
+}
+
+// EqualityProof is a Chaum-Pedersen proof that log_g(A) equals log_alpha(B)
+type EqualityProof struct {
+	Challenge string
+	Response  string
+}
+
+func proveEquality(x, base1, base2, res1, res2 *big.Int) (EqualityProof, error) {
+	w, err := randomScalar()
+	if err != nil {
+		return EqualityProof{}, err
+	}
+	t1 := groupExp(base1, w)
+	t2 := groupExp(base2, w)
+	c := hashToScalar(base1, res1, base2, res2, t1, t2)
+	r := new(big.Int).Mul(c, x)
+	r.Add(r, w)
+	r.Mod(r, groupQ)
+	return EqualityProof{Challenge: bigToHex(c), Response: bigToHex(r)}, nil
+}
+
+func verifyEquality(p EqualityProof, base1, base2, res1, res2 *big.Int) error {
+	c, err := scalar(p.Challenge)
+	if err != nil {
+		return err
+	}
+	r, err := scalar(p.Response)
+	if err != nil {
+		return err
+	}
+	t1 := groupDiv(groupExp(base1, r), groupExp(res1, c))
+	t2 := groupDiv(groupExp(base2, r), groupExp(res2, c))
+	if hashToScalar(base1, res1, base2, res2, t1, t2).Cmp(c) != 0 {
+		return errors.New("The proof of equality does not verify.")
+	}
+	return nil
+}
+
+// DecryptionShare is the alpha^share of a trustee with the proof that used the correct share
+type DecryptionShare struct {
+	Factor string
+	Proof  EqualityProof
+}
+
+func (ts TrusteeShare) Decrypt(c Ciphertext) (DecryptionShare, error) {
+	alpha, _, err := c.elements()
+	if err != nil {
+		return DecryptionShare{}, err
+	}
+	share, err := scalar(ts.Share)
+	if err != nil {
+		return DecryptionShare{}, err
+	}
+	factor := groupExp(alpha, share)
+	proof, err := proveEquality(share, groupG, alpha, groupExp(groupG, share), factor)
+	if err != nil {
+		return DecryptionShare{}, err
+	}
+	return DecryptionShare{Factor: bigToHex(factor), Proof: proof}, nil
+}
+
+func (ee *ElectionEncryption) VerifyDecryptionShare(t ElectionTrustee, c Ciphertext, ds DecryptionShare) error {
+	alpha, _, err := c.elements()
+	if err != nil {
+		return err
+	}
+	vk, err := groupElement(t.VerificationKey)
+	if err != nil {
+		return err
+	}
+	factor, err := groupElement(ds.Factor)
+	if err != nil {
+		return err
+	}
+	return verifyEquality(ds.Proof, groupG, alpha, vk, factor)
+}
+
+// lagrangeAtZero is the coefficient of the index for the interpolation at zero
+func lagrangeAtZero(index int, indexes []int) *big.Int {
+	num := big.NewInt(1)
+	den := big.NewInt(1)
+	for _, j := range indexes {
+		if j == index {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(j)))
+		num.Mod(num, groupQ)
+		den.Mul(den, big.NewInt(int64(j-index)))
+		den.Mod(den, groupQ)
+	}
+	den.ModInverse(den, groupQ)
+	num.Mul(num, den)
+	return num.Mod(num, groupQ)
+}
+
+// CombineDecryptionShares returns the plaintext of the ciphertext from the trustees' shares.
+// The shares are mapped by the trustee's index and the plaintext is searched up to max.
+func (ee *ElectionEncryption) CombineDecryptionShares(c Ciphertext, shares map[int]DecryptionShare, max int) (int, error) {
+	if len(shares) < ee.Threshold {
+		return 0, errors.New("The decryption needs " + strconv.Itoa(ee.Threshold) + " shares.")
+	}
+	_, beta, err := c.elements()
+	if err != nil {
+		return 0, err
+	}
+	indexes := []int{}
+	for k := range shares {
+		indexes = append(indexes, k)
+	}
+	secret := big.NewInt(1)
+	for _, k := range indexes {
+		factor, err := groupElement(shares[k].Factor)
+		if err != nil {
+			return 0, err
+		}
+		secret = groupMul(secret, groupExp(factor, lagrangeAtZero(k, indexes)))
+	}
+	gm := groupDiv(beta, secret)
+	acc := big.NewInt(1)
+	for m := 0; m <= max; m++ {
+		if acc.Cmp(gm) == 0 {
+			return m, nil
+		}
+		acc = groupMul(acc, groupG)
+	}
+	return 0, errors.New("The decrypted value is bigger than " + strconv.Itoa(max) + ".")
+}
+
+// ZeroOneProof is a disjunctive Chaum-Pedersen proof that the ciphertext encrypts 0 or 1.
+// The real proof is for the encrypted value and the other one is simulated,
+// but the challenges should sum to the hash, so only one can be simulated.
+type ZeroOneProof struct {
+	Challenge0 string
+	Challenge1 string
+	Response0  string
+	Response1  string
+}
+
+// EncryptedBallot has a ciphertext for each choice of the poll with its proof,
+// and a proof that the ciphertexts sum to the number of the allowed selections
+type EncryptedBallot struct {
+	Ballot   map[string]Ciphertext
+	Proofs   map[string]ZeroOneProof
+	SumProof EqualityProof
+}
+
+// BallotContext binds the ballot's proofs to the voter and the poll,
+// so a ballot can not be copied by another voter
+func BallotContext(from, pollHash string) string {
+	return from + "-" + pollHash
+}
+
+func zeroOneCommitments(alpha, beta, h *big.Int, m int64, c, s *big.Int) (*big.Int, *big.Int) {
+	betaM := groupDiv(beta, groupExp(groupG, big.NewInt(m)))
+	t1 := groupDiv(groupExp(groupG, s), groupExp(alpha, c))
+	t2 := groupDiv(groupExp(h, s), groupExp(betaM, c))
+	return t1, t2
+}
+
+func zeroOneChallenge(context string, alpha, beta, t10, t20, t11, t21 *big.Int) *big.Int {
+	ctx := new(big.Int).SetBytes([]byte(context))
+	return hashToScalar(ctx, alpha, beta, t10, t20, t11, t21)
+}
+
+func proveZeroOne(context string, h *big.Int, c Ciphertext, m int64, r *big.Int) (ZeroOneProof, error) {
+	alpha, beta, err := c.elements()
+	if err != nil {
+		return ZeroOneProof{}, err
+	}
+	simC, err := randomScalar()
+	if err != nil {
+		return ZeroOneProof{}, err
+	}
+	simS, err := randomScalar()
+	if err != nil {
+		return ZeroOneProof{}, err
+	}
+	w, err := randomScalar()
+	if err != nil {
+		return ZeroOneProof{}, err
+	}
+	// the commitments of the real proof are g^w and h^w
+	ts := [2][2]*big.Int{}
+	ts[m] = [2]*big.Int{groupExp(groupG, w), groupExp(h, w)}
+	t1, t2 := zeroOneCommitments(alpha, beta, h, 1-m, simC, simS)
+	ts[1-m] = [2]*big.Int{t1, t2}
+
+	total := zeroOneChallenge(context, alpha, beta, ts[0][0], ts[0][1], ts[1][0], ts[1][1])
+	realC := new(big.Int).Sub(total, simC)
+	realC.Mod(realC, groupQ)
+	realS := new(big.Int).Mul(realC, r)
+	realS.Add(realS, w)
+	realS.Mod(realS, groupQ)
+
+	cs := [2]*big.Int{}
+	ss := [2]*big.Int{}
+	cs[m], ss[m] = realC, realS
+	cs[1-m], ss[1-m] = simC, simS
+	return ZeroOneProof{
+		Challenge0: bigToHex(cs[0]),
+		Challenge1: bigToHex(cs[1]),
+		Response0:  bigToHex(ss[0]),
+		Response1:  bigToHex(ss[1]),
+	}, nil
+}
+
+func verifyZeroOne(context string, h *big.Int, c Ciphertext, p ZeroOneProof) error {
+	alpha, beta, err := c.elements()
+	if err != nil {
+		return err
+	}
+	cs := [2]*big.Int{}
+	ss := [2]*big.Int{}
+	for i, v := range []string{p.Challenge0, p.Challenge1, p.Response0, p.Response1} {
+		n, err := scalar(v)
+		if err != nil {
+			return err
+		}
+		if i < 2 {
+			cs[i] = n
+		} else {
+			ss[i-2] = n
+		}
+	}
+	t10, t20 := zeroOneCommitments(alpha, beta, h, 0, cs[0], ss[0])
+	t11, t21 := zeroOneCommitments(alpha, beta, h, 1, cs[1], ss[1])
+	total := new(big.Int).Add(cs[0], cs[1])
+	total.Mod(total, groupQ)
+	if zeroOneChallenge(context, alpha, beta, t10, t20, t11, t21).Cmp(total) != 0 {
+		return errors.New("The proof that the ciphertext encrypts 0 or 1 does not verify.")
+	}
+	return nil
+}
+
+// EncryptBallot encrypts 1 for the selected choice and 0 for the rest of the choices,
+// and proves that the ballot is valid without revealing the selected choice
+func (ee *ElectionEncryption) EncryptBallot(context string, choices []string, selected string) (*EncryptedBallot, error) {
+	h, err := groupElement(ee.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	eb := &EncryptedBallot{Ballot: map[string]Ciphertext{}, Proofs: map[string]ZeroOneProof{}}
+	sum := EmptyCiphertext()
+	sumR := big.NewInt(0)
+	for _, v := range choices {
+		m := int64(0)
+		if v == selected {
+			m = 1
+			found = true
+		}
+		c, r, err := ee.Encrypt(m)
+		if err != nil {
+			return nil, err
+		}
+		eb.Ballot[v] = c
+		eb.Proofs[v], err = proveZeroOne(context, h, c, m, r)
+		if err != nil {
+			return nil, err
+		}
+		sum, err = sum.Add(c)
+		if err != nil {
+			return nil, err
+		}
+		sumR.Add(sumR, r)
+		sumR.Mod(sumR, groupQ)
+	}
+	if !found {
+		return nil, errors.New("The choice " + selected + " does not exists.")
+	}
+	alpha, beta, err := sum.elements()
+	if err != nil {
+		return nil, err
+	}
+	// the sum encrypts 1, so log_g(alpha) equals log_h(beta/g)
+	eb.SumProof, err = proveEquality(sumR, groupG, h, alpha, groupDiv(beta, groupG))
+	if err != nil {
+		return nil, err
+	}
+	return eb, nil
+}
+
+// VerifyBallot checks that every ciphertext encrypts 0 or 1
+// and that the ciphertexts sum to the number of selections
+func (ee *ElectionEncryption) VerifyBallot(context string, eb EncryptedBallot, selections int) error {
+	h, err := groupElement(ee.PublicKey)
+	if err != nil {
+		return err
+	}
+	sum := EmptyCiphertext()
+	for k, v := range eb.Ballot {
+		p, ok := eb.Proofs[k]
+		if !ok {
+			return errors.New("The ciphertext for the choice " + k + " does not have a proof.")
+		}
+		err = verifyZeroOne(context, h, v, p)
+		if err != nil {
+			return errors.New("The ciphertext for the choice " + k + " is not correct: " + err.Error())
+		}
+		sum, err = sum.Add(v)
+		if err != nil {
+			return err
+		}
+	}
+	alpha, beta, err := sum.elements()
+	if err != nil {
+		return err
+	}
+	betaM := groupDiv(beta, groupExp(groupG, big.NewInt(int64(selections))))
+	err = verifyEquality(eb.SumProof, groupG, h, alpha, betaM)
+	if err != nil {
+		return errors.New("The ballot does not have " + strconv.Itoa(selections) + " selections: " + err.Error())
+	}
+	return nil
+}
+
+// DecryptChoices creates the trustee's decryption shares for the summed ciphertexts of a poll
+func (ts TrusteeShare) DecryptChoices(encryptedChoices map[string]Ciphertext) (map[string]DecryptionShare, error) {
+	shares := map[string]DecryptionShare{}
+	for k, v := range encryptedChoices {
+		ds, err := ts.Decrypt(v)
+		if err != nil {
+			return nil, err
+		}
+		shares[k] = ds
+	}
+	return shares, nil
+}
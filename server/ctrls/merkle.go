@@ -0,0 +1,112 @@
+package ctrls
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// The voters' roll can be a Merkle tree of the voters' public keys, so the election keeps only the root,
+// and each voter proves the membership with the hashes from the leaf to the root.
+// The leaves and the nodes are hashed with a different prefix, so a node can not be used as a leaf.
+// When a level has an odd number of nodes, the last node moves to the next level without hashing.
+
+const merkleMaxDepth = 64
+
+type MerkleProofStep struct {
+	Hash string
+	Left bool // the hash is on the left of the path
+}
+
+type MerkleProof []MerkleProofStep
+
+func merkleLeaf(pubHex string) []byte {
+	h := sha256.Sum256(append([]byte{0x00}, []byte(pubHex)...))
+	return h[:]
+}
+
+func merkleNode(left, right []byte) []byte {
+	b := append([]byte{0x01}, left...)
+	h := sha256.Sum256(append(b, right...))
+	return h[:]
+}
+
+type MerkleTree struct {
+	levels  [][][]byte
+	indexes map[string]int
+}
+
+func NewMerkleTree(voters []string) (*MerkleTree, error) {
+	if len(voters) == 0 {
+		return nil, errors.New("The voters are empty.")
+	}
+	mt := new(MerkleTree)
+	mt.indexes = map[string]int{}
+	level := [][]byte{}
+	for i, v := range voters {
+		_, ok := mt.indexes[v]
+		if ok {
+			return nil, errors.New("The voter " + v + " exists already in the list.")
+		}
+		mt.indexes[v] = i
+		level = append(level, merkleLeaf(v))
+	}
+	mt.levels = append(mt.levels, level)
+	for len(level) > 1 {
+		next := [][]byte{}
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, merkleNode(level[i], level[i+1]))
+			}
+		}
+		mt.levels = append(mt.levels, next)
+		level = next
+	}
+	return mt, nil
+}
+
+func (mt *MerkleTree) Root() string {
+	return hex.EncodeToString(mt.levels[len(mt.levels)-1][0])
+}
+
+func (mt *MerkleTree) Proof(pubHex string) (MerkleProof, error) {
+	index, ok := mt.indexes[pubHex]
+	if !ok {
+		return nil, errors.New("The voter " + pubHex + " is not in the list.")
+	}
+	proof := MerkleProof{}
+	for _, level := range mt.levels[:len(mt.levels)-1] {
+		if index%2 == 1 {
+			proof = append(proof, MerkleProofStep{Hash: hex.EncodeToString(level[index-1]), Left: true})
+		} else if index+1 < len(level) {
+			proof = append(proof, MerkleProofStep{Hash: hex.EncodeToString(level[index+1]), Left: false})
+		}
+		index = index / 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks that the voter is a leaf of the tree with the root in hex
+func VerifyMerkleProof(rootHex, pubHex string, proof MerkleProof) error {
+	if len(proof) > merkleMaxDepth {
+		return errors.New("The membership proof is too long.")
+	}
+	h := merkleLeaf(pubHex)
+	for _, v := range proof {
+		sibling, err := hex.DecodeString(v.Hash)
+		if err != nil || len(sibling) != sha256.Size {
+			return errors.New("The membership proof has not a correct hash.")
+		}
+		if v.Left {
+			h = merkleNode(sibling, h)
+		} else {
+			h = merkleNode(h, sibling)
+		}
+	}
+	if hex.EncodeToString(h) != rootHex {
+		return errors.New("The membership proof does not verify the voters' root.")
+	}
+	return nil
+}
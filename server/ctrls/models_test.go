@@ -79,3 +79,47 @@ func TestModelElectionDeliveryFailOnPublicKeyVoter(t *testing.T) {
 	err := ed.ValidateVoters()
 	assert.NotNil(t, err)
 }
+
+func TestModelRingSignatureLinksTheSameVoter(t *testing.T) {
+	voters, voterHexs := forTestVoters(t, 4)
+	msg := []byte("message")
+
+	keyImage, err := RingKeyImage(voters[2], "scope")
+	assert.Nil(t, err)
+	sig, err := SignRing(voters[2], voterHexs, "scope", msg)
+	assert.Nil(t, err)
+	assert.Nil(t, VerifyRing(voterHexs, "scope", keyImage, msg, sig))
+	assert.NotNil(t, VerifyRing(voterHexs, "scope", keyImage, []byte("other"), sig))
+	assert.NotNil(t, VerifyRing(voterHexs, "other", keyImage, msg, sig))
+
+	otherImage, err := RingKeyImage(voters[1], "scope")
+	assert.Nil(t, err)
+	assert.NotEqual(t, keyImage, otherImage)
+	assert.NotNil(t, VerifyRing(voterHexs, "scope", otherImage, msg, sig))
+
+	scopeImage, err := RingKeyImage(voters[2], "other")
+	assert.Nil(t, err)
+	assert.NotEqual(t, keyImage, scopeImage)
+}
+
+func TestModelMerkleProofsForAllVoters(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		_, voterHexs := forTestVoters(t, n)
+		mt, err := NewMerkleTree(voterHexs)
+		assert.Nil(t, err)
+		for _, v := range voterHexs {
+			proof, err := mt.Proof(v)
+			assert.Nil(t, err)
+			assert.Nil(t, VerifyMerkleProof(mt.Root(), v, proof))
+		}
+		_, otherHexs := forTestVoters(t, 1)
+		proof, _ := mt.Proof(voterHexs[0])
+		assert.NotNil(t, VerifyMerkleProof(mt.Root(), otherHexs[0], proof))
+	}
+}
+
+func TestModelMerkleTreeFailOnDuplicateVoter(t *testing.T) {
+	_, voterHexs := forTestVoters(t, 2)
+	_, err := NewMerkleTree(append(voterHexs, voterHexs[0]))
+	assert.NotNil(t, err)
+}
@@ -2,6 +2,8 @@ package ctrls
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 
 	"github.com/tendermint/abci/types"
 )
@@ -30,27 +32,326 @@ func (tva *TVApplication) queryListPolls() ListPollQuery {
 		return list
 	}
 	for _, v := range tva.state.GetPolls() {
-		item := ItemPollQuery{}
-		item.PollQuery = v
-		if v.PollHash == latest {
-			item.Latest = true
-		}
-		list = append(list, item)
+		list = append(list, tva.pollItem(v.PollHash, latest))
 	}
 	return list
 }
 
+func (tva *TVApplication) pollItem(pollHash, latest string) ItemPollQuery {
+	item := ItemPollQuery{}
+	item.PollHash = pollHash
+	item.Latest = pollHash == latest
+	ps, err := tva.state.GetPoll(pollHash)
+	if err == nil && ps.Cancelled != nil {
+		item.Void = true
+		item.Replacement = ps.Cancelled.Replacement
+	}
+	return item
+}
+
 func (tva *TVApplication) queryVotes(pollHash string) (*PollVotesQuery, error) {
 	ps, err := tva.state.GetPoll(pollHash)
 	if err != nil {
 		return nil, err
 	}
 	pvq := new(PollVotesQuery)
-	pvq.Choices = ps.Choices
 	pvq.NumberOfVotes = len(ps.VotedAlready)
+	pvq.Encrypted = ps.Encrypted
+	pvq.Tallied = ps.Tallied
+	pvq.Method = ps.Method
+	pvq.GroupVotes = ps.GroupVotes
+	pvq.Paused = ps.Paused
+	// the cancelled poll keeps its results for the audit, but they are void
+	pvq.Void = ps.Cancelled != nil
+	pvq.Cancelled = ps.Cancelled
+	pvq.EndHeight = ps.EndHeight
+	pvq.Runoff = ps.Runoff
+	pvq.RunoffOf = ps.RunoffOf
+	pvq.EmbargoOverrides = ps.EmbargoOverrides
+	if ps.Credentials {
+		es, err := tva.state.GetElection(ps.ElectionID)
+		if err != nil {
+			return nil, err
+		}
+		pvq.IssuedCredentials = es.IssuedCredentials
+		pvq.CredentialVotes = len(ps.VotedAlready)
+	}
+	// the embargo shows only the turnout while the poll is open
+	if tva.state.IsEmbargoed(ps) {
+		pvq.Embargoed = true
+		return pvq, nil
+	}
+	pvq.Choices = ps.Choices
+	pvq.WriteIns = ps.WriteIns
+	pvq.WriteInMerges = ps.WriteInMerges
+	pvq.GroupChoices = ps.GroupChoices
+	switch ps.Method {
+	case METHOD_SCHULZE:
+		pvq.Pairwise = ps.Pairwise
+		pvq.Ranking = SchulzeRanking(ps.Pairwise)
+	case METHOD_SCORE:
+		pvq.AverageScores = AverageScores(ps.Choices, pvq.NumberOfVotes)
+	case METHOD_BUDGETING:
+		pvq.Funded = FundedProjects(ps.Choices, ps.Costs, ps.Budget)
+	}
+	if len(ps.Questions) > 0 {
+		pvq.Questions = map[string]map[string]int{}
+		for k, v := range ps.Questions {
+			pvq.Questions[k] = v.Choices
+		}
+	}
 	return pvq, nil
 }
 
+func (tva *TVApplication) queryPollEncryption(pollHash string) (*PollEncryptionQuery, error) {
+	ps, err := tva.state.GetPoll(pollHash)
+	if err != nil {
+		return nil, err
+	}
+	if !ps.Encrypted {
+		return nil, errors.New("The poll " + pollHash + " is not encrypted.")
+	}
+	es, err := tva.state.GetElection(ps.ElectionID)
+	if err != nil {
+		return nil, err
+	}
+	peq := new(PollEncryptionQuery)
+	peq.PollHash = ps.PollHash
+	peq.Encryption = es.Encryption
+	peq.EncryptedChoices = ps.EncryptedChoices
+	peq.Decryptions = len(ps.DecryptionShares)
+	peq.Tallied = ps.Tallied
+	return peq, nil
+}
+
+func (tva *TVApplication) queryPollRing(pollHash string) (*PollRingQuery, error) {
+	ps, err := tva.state.GetPoll(pollHash)
+	if err != nil {
+		return nil, err
+	}
+	if !ps.Anonymous {
+		return nil, errors.New("The poll " + pollHash + " is not anonymous.")
+	}
+	es, err := tva.state.GetElection(ps.ElectionID)
+	if err != nil {
+		return nil, err
+	}
+	prq := new(PollRingQuery)
+	prq.PollHash = ps.PollHash
+	prq.Voters = es.Voters
+	return prq, nil
+}
+
+// queryPollVoted checks if the key voted in the poll, and the key is the voter's public key,
+// the anonymous vote's key image or the credential's voting key
+func (tva *TVApplication) queryPollVoted(pvq PollVotedQuery) (*PollVotedQuery, error) {
+	ps, err := tva.state.GetPoll(pvq.PollHash)
+	if err != nil {
+		return nil, err
+	}
+	pvq.Voted = false
+	for _, v := range ps.VotedAlready {
+		if v == pvq.From {
+			pvq.Voted = true
+			break
+		}
+	}
+	return &pvq, nil
+}
+
+// queryCredential returns the election's credential key, even if the voter has not requested a credential yet
+func (tva *TVApplication) queryCredential(cq CredentialQuery) (*CredentialStateQuery, error) {
+	es, err := tva.state.GetElection(cq.ElectionID)
+	if err != nil {
+		return nil, err
+	}
+	if es.Credentials == nil {
+		return nil, errors.New("The election " + cq.ElectionID + " does not have credentials.")
+	}
+	csq := new(CredentialStateQuery)
+	csq.CredentialQuery = cq
+	csq.Key = es.Credentials
+	cs, err := tva.state.GetCredential(cq.ElectionID, cq.Voter)
+	if err == nil {
+		csq.Blinded = cs.Blinded
+		csq.BlindSignature = cs.BlindSignature
+	}
+	return csq, nil
+}
+
+func (tva *TVApplication) queryElectionAmendments(id string) (*ElectionAmendmentsQuery, error) {
+	es, err := tva.state.GetElection(id)
+	if err != nil {
+		return nil, err
+	}
+	eaq := new(ElectionAmendmentsQuery)
+	eaq.ID = es.ID
+	eaq.Voters = es.Voters
+	eaq.Amendments = es.Amendments
+	return eaq, nil
+}
+
+// queryElectionEncryption returns the trustees with their dealings, that the trustees need for the election's key
+func (tva *TVApplication) queryElectionEncryption(id string) (*ElectionEncryption, error) {
+	es, err := tva.state.GetElection(id)
+	if err != nil {
+		return nil, err
+	}
+	if es.Encryption == nil {
+		return nil, errors.New("The election " + id + " is not encrypted.")
+	}
+	return es.Encryption, nil
+}
+
+func (tva *TVApplication) queryElectionDetails(id string) (*ElectionDetailsQuery, error) {
+	es, err := tva.state.GetElection(id)
+	if err != nil {
+		return nil, err
+	}
+	latest, _ := tva.state.GetLatestPoll()
+	edq := new(ElectionDetailsQuery)
+	edq.ID = es.ID
+	edq.NumberOfVoters = es.NumberOfVoters()
+	edq.Status = es.Status
+	edq.Name = es.Name
+	edq.Description = es.Description
+	edq.Jurisdiction = es.Jurisdiction
+	edq.CreatedHeight = es.CreatedHeight
+	edq.CreatedTime = es.CreatedTime
+	edq.Creator = es.Creator
+	edq.Groups = es.Groups
+	edq.Paused = es.Paused
+	edq.InitiativeShare = es.InitiativeShare
+	edq.Invitations = len(es.Invitations)
+	edq.PendingInvitations = es.PendingInvitations()
+	edq.Polls = ListPollQuery{}
+	for _, v := range es.Polls {
+		edq.Polls = append(edq.Polls, tva.pollItem(v, latest))
+	}
+	return edq, nil
+}
+
+func (tva *TVApplication) queryInitiative(pollHash string) (*InitiativeQuery, error) {
+	is, err := tva.state.GetInitiative(pollHash)
+	if err != nil {
+		return nil, err
+	}
+	es, err := tva.state.GetElection(is.ElectionID)
+	if err != nil {
+		return nil, err
+	}
+	iq := new(InitiativeQuery)
+	iq.PollHash = is.PollHash
+	iq.ElectionID = is.ElectionID
+	iq.Proposer = is.Proposer
+	iq.Height = is.Height
+	iq.Supporters = len(is.Supporters)
+	iq.Needed = is.NeededSupporters(es)
+	iq.Created = is.Created
+	return iq, nil
+}
+
+func (tva *TVApplication) queryListInitiatives() ListInitiativeQuery {
+	list := ListInitiativeQuery{}
+	for _, v := range tva.state.GetInitiatives() {
+		iq, err := tva.queryInitiative(v)
+		if err == nil {
+			list = append(list, *iq)
+		}
+	}
+	return list
+}
+
+// queryPendingRegistrations returns the registrations that wait for the gonverment's decision
+func (tva *TVApplication) queryPendingRegistrations(electionID string) (ListRegistrationQuery, error) {
+	_, err := tva.state.GetElection(electionID)
+	if err != nil {
+		return nil, err
+	}
+	list := ListRegistrationQuery{}
+	for _, v := range tva.state.GetRegistrations(electionID) {
+		rs, err := tva.state.GetRegistration(electionID, v)
+		if err == nil && rs.Status == REGISTRATION_PENDING {
+			list = append(list, *rs)
+		}
+	}
+	return list, nil
+}
+
+// queryVoterStatus returns the voter's elections from the voter's index, and the open polls of each election
+// that the voter can vote with the key
+func (tva *TVApplication) queryVoterStatus(voter string) *VoterStatusQuery {
+	vsq := new(VoterStatusQuery)
+	vsq.Voter = voter
+	vsq.Elections = []VoterElectionQuery{}
+	for _, id := range tva.state.GetVoterElections(voter) {
+		es, err := tva.state.GetElection(id)
+		if err != nil || !es.HasVoter(voter) {
+			continue
+		}
+		veq := VoterElectionQuery{ID: es.ID, Name: es.Name, Status: es.Status, Groups: es.Groups[voter]}
+		veq.Polls = tva.voterPolls(es, voter)
+		vsq.Elections = append(vsq.Elections, veq)
+	}
+	// the node does not know the voters of the elections with the voters' root, so they are listed for every key,
+	// and the membership is proved with the Merkle proof of the vote
+	for _, id := range tva.state.GetVotersRootElections() {
+		es, err := tva.state.GetElection(id)
+		if err != nil {
+			continue
+		}
+		veq := VoterElectionQuery{ID: es.ID, Name: es.Name, Status: es.Status, MembershipByProof: true}
+		veq.Polls = tva.voterPolls(es, voter)
+		vsq.Elections = append(vsq.Elections, veq)
+	}
+	return vsq
+}
+
+// voterPolls returns the election's polls that are open for the voter
+func (tva *TVApplication) voterPolls(es *ElectionState, voter string) []VoterPollQuery {
+	list := []VoterPollQuery{}
+	for _, v := range es.Polls {
+		ps, err := tva.state.GetPoll(v)
+		if err != nil || !tva.isOpenForVoter(es, ps, voter) {
+			continue
+		}
+		vpq := VoterPollQuery{PollHash: ps.PollHash, Paused: ps.Paused, EndHeight: ps.EndHeight}
+		if es.Paused != nil {
+			vpq.Paused = es.Paused
+		}
+		vpq.Anonymous = ps.Anonymous || ps.Credentials
+		if !vpq.Anonymous {
+			vpq.Voted = tva.state.HasVote(VoteDeliveryData{From: voter, PollHash: ps.PollHash})
+		}
+		list = append(list, vpq)
+	}
+	return list
+}
+
+// isOpenForVoter checks the poll like the vote's delivery, except for the pause,
+// so the voter sees the paused polls too
+func (tva *TVApplication) isOpenForVoter(es *ElectionState, ps *PollState, voter string) bool {
+	if es.Status != ELECTION_OPEN || ps.Cancelled != nil || ps.HasEnded(tva.state.Height) {
+		return false
+	}
+	if !tva.state.IsLatestPoll(ps.PollHash) {
+		return false
+	}
+	return len(ps.Groups) == 0 || len(es.VoterGroups(voter, ps.Groups)) > 0
+}
+
+// pathParam returns the parameter of the path like prefix{param}, only when the parameter is one segment
+func pathParam(path, prefix string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+	param := strings.TrimPrefix(path, prefix)
+	if len(param) == 0 || strings.Contains(param, "/") {
+		return "", false
+	}
+	return param, true
+}
+
 func (tva *TVApplication) Query(qreq types.RequestQuery) types.ResponseQuery {
 	switch qreq.Path {
 	case "/elections":
@@ -67,6 +368,36 @@ func (tva *TVApplication) Query(qreq types.RequestQuery) types.ResponseQuery {
 				return resp
 			}
 		}
+	case "/elections/amendments":
+		eq := ElectionQuery{}
+		err := json.Unmarshal(qreq.Data, &eq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeEncodingError, Log: "The JSON for the election's ID is incorrect."}
+			return resp
+		}
+		eaq, err := tva.queryElectionAmendments(eq.ID)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+			return resp
+		}
+		b, _ := json.Marshal(eaq)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	case "/elections/encryption":
+		eq := ElectionQuery{}
+		err := json.Unmarshal(qreq.Data, &eq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeEncodingError, Log: "The JSON for the election's ID is incorrect."}
+			return resp
+		}
+		enc, err := tva.queryElectionEncryption(eq.ID)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+			return resp
+		}
+		b, _ := json.Marshal(enc)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
 	case "/polls":
 		list := tva.queryListPolls()
 		b, _ := json.Marshal(list)
@@ -96,6 +427,125 @@ func (tva *TVApplication) Query(qreq types.RequestQuery) types.ResponseQuery {
 		b, _ := json.Marshal(pvq)
 		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
 		return resp
+	case "/polls/scheduled":
+		list := tva.state.GetScheduledPolls()
+		b, _ := json.Marshal(list)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	case "/polls/encryption":
+		pq := PollQuery{}
+		err := json.Unmarshal(qreq.Data, &pq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeEncodingError, Log: "The JSON for the poll hash is incorrect."}
+			return resp
+		}
+		peq, err := tva.queryPollEncryption(pq.PollHash)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+			return resp
+		}
+		b, _ := json.Marshal(peq)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	case "/polls/ring":
+		pq := PollQuery{}
+		err := json.Unmarshal(qreq.Data, &pq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeEncodingError, Log: "The JSON for the poll hash is incorrect."}
+			return resp
+		}
+		prq, err := tva.queryPollRing(pq.PollHash)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+			return resp
+		}
+		b, _ := json.Marshal(prq)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	case "/polls/voted":
+		pvq := PollVotedQuery{}
+		err := json.Unmarshal(qreq.Data, &pvq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeEncodingError, Log: "The JSON for the poll's voter is incorrect."}
+			return resp
+		}
+		voted, err := tva.queryPollVoted(pvq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+			return resp
+		}
+		b, _ := json.Marshal(voted)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	case "/credentials":
+		cq := CredentialQuery{}
+		err := json.Unmarshal(qreq.Data, &cq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeEncodingError, Log: "The JSON for the credential is incorrect."}
+			return resp
+		}
+		csq, err := tva.queryCredential(cq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+			return resp
+		}
+		b, _ := json.Marshal(csq)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	case "/initiatives":
+		list := tva.queryListInitiatives()
+		b, _ := json.Marshal(list)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	case "/initiatives/support":
+		pq := PollQuery{}
+		err := json.Unmarshal(qreq.Data, &pq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeEncodingError, Log: "The JSON for the poll hash is incorrect."}
+			return resp
+		}
+		iq, err := tva.queryInitiative(pq.PollHash)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+			return resp
+		}
+		b, _ := json.Marshal(iq)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	case "/registrations/pending":
+		eq := ElectionQuery{}
+		err := json.Unmarshal(qreq.Data, &eq)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeEncodingError, Log: "The JSON for the election's ID is incorrect."}
+			return resp
+		}
+		list, err := tva.queryPendingRegistrations(eq.ID)
+		if err != nil {
+			resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+			return resp
+		}
+		b, _ := json.Marshal(list)
+		resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+		return resp
+	default:
+		// the election's ID is in the path, like /elections/{id}
+		if id, ok := pathParam(qreq.Path, "/elections/"); ok {
+			edq, err := tva.queryElectionDetails(id)
+			if err != nil {
+				resp := types.ResponseQuery{Code: CodeTypeUnauthorized, Log: err.Error()}
+				return resp
+			}
+			b, _ := json.Marshal(edq)
+			resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+			return resp
+		}
+		// the voter's public key is in the path, like /voters/{pubkey}
+		if voter, ok := pathParam(qreq.Path, "/voters/"); ok {
+			vsq := tva.queryVoterStatus(voter)
+			b, _ := json.Marshal(vsq)
+			resp := types.ResponseQuery{Code: CodeTypeOK, Value: b}
+			return resp
+		}
 	}
 
 	resp := types.ResponseQuery{Code: CodeTypeOK}
@@ -6,7 +6,9 @@ type PollQuery struct {
 
 type ItemPollQuery struct {
 	PollQuery
-	Latest bool
+	Latest      bool
+	Void        bool   `json:",omitempty"`
+	Replacement string `json:",omitempty"`
 }
 
 type ListPollQuery []ItemPollQuery
@@ -14,6 +16,7 @@ type ListPollQuery []ItemPollQuery
 type ElectionQuery struct {
 	ID             string
 	NumberOfVoters int
+	Status         ElectionStatus
 }
 
 type ItemElectionQuery struct {
@@ -26,4 +29,122 @@ type ListElectionQuery []ItemElectionQuery
 type PollVotesQuery struct {
 	Choices       map[string]int
 	NumberOfVotes int
+	Encrypted     bool
+	Tallied       bool
+	Questions     map[string]map[string]int `json:",omitempty"`
+	WriteIns      map[string]int            `json:",omitempty"`
+	WriteInMerges []WriteInMerge            `json:",omitempty"`
+	Method        VotingMethod              `json:",omitempty"`
+	Pairwise      map[string]map[string]int `json:",omitempty"`
+	Ranking       []string                  `json:",omitempty"`
+	AverageScores map[string]float64        `json:",omitempty"`
+	Funded        []string                  `json:",omitempty"`
+	GroupVotes    map[string]int            `json:",omitempty"`
+	GroupChoices  map[string]map[string]int `json:",omitempty"`
+	Paused        *PauseState               `json:",omitempty"`
+	Void          bool                      `json:",omitempty"`
+	Cancelled     *CancelState              `json:",omitempty"`
+	EndHeight     int64                     `json:",omitempty"`
+	Runoff        string                    `json:",omitempty"`
+	RunoffOf      string                    `json:",omitempty"`
+	// the results are hidden, except the turnout, until the poll closes
+	Embargoed        bool              `json:",omitempty"`
+	EmbargoOverrides []EmbargoOverride `json:",omitempty"`
+	// the issued credentials and the credential votes, so the auditors can check that the votes are not more
+	IssuedCredentials int `json:",omitempty"`
+	CredentialVotes   int `json:",omitempty"`
+}
+
+type PollEncryptionQuery struct {
+	PollHash         string
+	Encryption       *ElectionEncryption
+	EncryptedChoices map[string]Ciphertext
+	Decryptions      int
+	Tallied          bool
+}
+
+type PollRingQuery struct {
+	PollHash string
+	Voters   []string
+}
+
+type PollVotedQuery struct {
+	PollHash string
+	From     string
+	Voted    bool
+}
+
+type CredentialQuery struct {
+	ElectionID string
+	Voter      string
+}
+
+type CredentialStateQuery struct {
+	CredentialQuery
+	Key            *CredentialKey
+	Blinded        string
+	BlindSignature string
+}
+
+type ElectionAmendmentsQuery struct {
+	ID         string
+	Voters     []string
+	Amendments []VotersAmendment
+}
+
+type ElectionDetailsQuery struct {
+	ElectionQuery
+	Name            string
+	Description     string
+	Jurisdiction    string
+	CreatedHeight   int64
+	CreatedTime     int64
+	Creator         string
+	Polls           ListPollQuery
+	Groups          map[string][]string `json:",omitempty"`
+	Paused          *PauseState         `json:",omitempty"`
+	InitiativeShare int                 `json:",omitempty"`
+	// the number of the invitations, and of those that are not redeemed
+	Invitations        int `json:",omitempty"`
+	PendingInvitations int `json:",omitempty"`
+}
+
+type InitiativeQuery struct {
+	PollHash   string
+	ElectionID string
+	Proposer   string
+	Height     int64
+	Supporters int
+	Needed     int
+	Created    bool
+}
+
+type ListInitiativeQuery []InitiativeQuery
+
+type ListRegistrationQuery []RegistrationState
+
+// VoterPollQuery is an open poll that the voter can vote, and the anonymous polls or the polls with credentials
+// have the votes with other keys, so it is not known if the voter voted
+type VoterPollQuery struct {
+	PollHash  string
+	Voted     bool
+	Anonymous bool        `json:",omitempty"`
+	Paused    *PauseState `json:",omitempty"`
+	EndHeight int64       `json:",omitempty"`
+}
+
+type VoterElectionQuery struct {
+	ID     string
+	Name   string
+	Status ElectionStatus
+	Groups []string `json:",omitempty"`
+	Polls  []VoterPollQuery
+	// the election has the voters' root, so the node can not check that the key is a voter,
+	// and the voter proves it with the Merkle proof at the vote
+	MembershipByProof bool `json:",omitempty"`
+}
+
+type VoterStatusQuery struct {
+	Voter     string
+	Elections []VoterElectionQuery
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/mragiadakos/tendervoting/server/confs"
 	"github.com/stretchr/testify/assert"
 	"github.com/tendermint/abci/types"
 )
@@ -122,3 +123,89 @@ func TestQueryVotes(t *testing.T) {
 	assert.Equal(t, 100, pvq.Choices["a"])
 	assert.Equal(t, 100, pvq.NumberOfVotes)
 }
+
+func TestQueryPollVoted(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	voters, voterHexs := forTestVoters(t, 2)
+	electionID := forTestCreateElection(t, app, gov, voterHexs)
+	pollHash := forTestCreatePoll(t, app, gov, electionID, map[string]string{"a": "a", "b": "b"})
+	forTestCreateVote(t, app, voters[0], electionID, pollHash, "a")
+
+	qreq := types.RequestQuery{}
+	qreq.Path = "/polls/voted"
+	qreq.Data, _ = json.Marshal(PollVotedQuery{PollHash: pollHash, From: voterHexs[0]})
+	qresp := app.Query(qreq)
+	assert.Equal(t, CodeTypeOK, qresp.Code)
+	pvq := PollVotedQuery{}
+	json.Unmarshal(qresp.Value, &pvq)
+	assert.True(t, pvq.Voted)
+
+	qreq.Data, _ = json.Marshal(PollVotedQuery{PollHash: pollHash, From: voterHexs[1]})
+	qresp = app.Query(qreq)
+	pvq = PollVotedQuery{}
+	json.Unmarshal(qresp.Value, &pvq)
+	assert.False(t, pvq.Voted)
+}
+
+func TestQueryElectionDetails(t *testing.T) {
+	app := NewTVApplication()
+	privk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+
+	pubB, _ := privk.GetPublic().Bytes()
+	pubHex := hex.EncodeToString(pubB)
+	app.BeginBlock(types.RequestBeginBlock{Header: types.Header{Height: 7, Time: 1530000000}})
+	electionID := forTestDeliverElection(t, app, privk, ElectionDeliveryData{
+		Voters:       []string{pubHex},
+		Name:         "Referendum",
+		Description:  "The referendum for the new law",
+		Jurisdiction: "Athens",
+	})
+	first := forTestCreatePoll(t, app, privk, electionID, map[string]string{"y": "yes"})
+	latest := forTestCreatePoll(t, app, privk, electionID, map[string]string{"n": "no"})
+
+	qreq := types.RequestQuery{}
+	qreq.Path = "/elections/" + electionID
+	qresp := app.Query(qreq)
+	assert.Equal(t, CodeTypeOK, qresp.Code)
+	edq := ElectionDetailsQuery{}
+	json.Unmarshal(qresp.Value, &edq)
+	assert.Equal(t, electionID, edq.ID)
+	assert.Equal(t, "Referendum", edq.Name)
+	assert.Equal(t, "The referendum for the new law", edq.Description)
+	assert.Equal(t, "Athens", edq.Jurisdiction)
+	assert.Equal(t, int64(7), edq.CreatedHeight)
+	assert.Equal(t, int64(1530000000), edq.CreatedTime)
+	assert.Equal(t, pubHex, edq.Creator)
+	assert.Equal(t, 1, edq.NumberOfVoters)
+	assert.Equal(t, ELECTION_OPEN, edq.Status)
+	assert.Equal(t, 2, len(edq.Polls))
+	assert.Equal(t, first, edq.Polls[0].PollHash)
+	assert.False(t, edq.Polls[0].Latest)
+	assert.Equal(t, latest, edq.Polls[1].PollHash)
+	assert.True(t, edq.Polls[1].Latest)
+
+	qreq.Path = "/elections/unknown"
+	qresp = app.Query(qreq)
+	assert.Equal(t, CodeTypeUnauthorized, qresp.Code)
+
+	// the path should have only the election's ID
+	qreq.Path = "/elections/" + electionID + "/polls"
+	qresp = app.Query(qreq)
+	assert.Equal(t, 0, len(qresp.Value))
+}
+
+func TestQueryElectionFailOnReservedID(t *testing.T) {
+	app := NewTVApplication()
+	gov, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	assert.Nil(t, err)
+	pubB, _ := gov.GetPublic().Bytes()
+
+	for _, id := range []string{"latest", "amendments", "encryption", "a/b"} {
+		ed := ElectionDeliveryData{ID: id, From: hex.EncodeToString(pubB), Voters: []string{hex.EncodeToString(pubB)}}
+		confs.Conf.GonvermentPublicKeyHex = ed.From
+		assert.Equal(t, CodeTypeUnauthorized, forTestDeliver(t, app, gov, ELECTION, &ed))
+	}
+}
@@ -0,0 +1,364 @@
+package ctrls
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/agl/ed25519/edwards25519"
+	crypto "github.com/libp2p/go-libp2p-crypto"
+)
+
+// The ring signature is a linkable spontaneous anonymous group signature (LSAG) on ed25519,
+// so the voter signs for the whole list of voters without revealing which voter signed.
+// The key image x*Hp(P||scope) is the same for every signature of the same voter in the same scope,
+// so a voter can not sign twice for the same poll.
+
+var (
+	curveOrder    [32]byte // l, the order of the base point
+	curveMinusOne [32]byte // l-1, for the negation of the scalars
+	curveD2       edwards25519.FieldElement
+	curveIdentity = [32]byte{1}
+)
+
+func init() {
+	l, _ := new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+	curveOrder = bigToLittleEndian(l)
+	curveMinusOne = bigToLittleEndian(new(big.Int).Sub(l, big.NewInt(1)))
+
+	// 2*d where d = -121665/121666 mod 2^255-19
+	p := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	d := new(big.Int).ModInverse(big.NewInt(121666), p)
+	d.Mul(d, big.NewInt(-121665))
+	d.Mul(d, big.NewInt(2))
+	d.Mod(d, p)
+	d2 := bigToLittleEndian(d)
+	edwards25519.FeFromBytes(&curveD2, &d2)
+}
+
+func bigToLittleEndian(n *big.Int) [32]byte {
+	out := [32]byte{}
+	b := n.Bytes()
+	for i, v := range b {
+		out[len(b)-1-i] = v
+	}
+	return out
+}
+
+func ringRandomScalar() ([32]byte, error) {
+	var wide [64]byte
+	var out [32]byte
+	_, err := rand.Read(wide[:])
+	if err != nil {
+		return out, err
+	}
+	edwards25519.ScReduce(&out, &wide)
+	return out, nil
+}
+
+func ringHashToScalar(parts ...[]byte) [32]byte {
+	h := sha512.New()
+	for _, v := range parts {
+		h.Write(v)
+	}
+	var wide [64]byte
+	var out [32]byte
+	copy(wide[:], h.Sum(nil))
+	edwards25519.ScReduce(&out, &wide)
+	return out
+}
+
+func pointToBytes(p *edwards25519.ExtendedGroupElement) []byte {
+	var b [32]byte
+	p.ToBytes(&b)
+	return b[:]
+}
+
+func pointFromBytes(b []byte) (*edwards25519.ExtendedGroupElement, error) {
+	if len(b) != 32 {
+		return nil, errors.New("The point should have 32 bytes.")
+	}
+	var s [32]byte
+	copy(s[:], b)
+	p := new(edwards25519.ExtendedGroupElement)
+	if !p.FromBytes(&s) {
+		return nil, errors.New("The point is not on the curve.")
+	}
+	return p, nil
+}
+
+func pointAdd(p, q *edwards25519.ExtendedGroupElement) *edwards25519.ExtendedGroupElement {
+	var a, b, t edwards25519.FieldElement
+	var c edwards25519.CompletedGroupElement
+	edwards25519.FeSub(&a, &p.Y, &p.X)
+	edwards25519.FeSub(&t, &q.Y, &q.X)
+	edwards25519.FeMul(&a, &a, &t)
+	edwards25519.FeAdd(&b, &p.Y, &p.X)
+	edwards25519.FeAdd(&t, &q.Y, &q.X)
+	edwards25519.FeMul(&b, &b, &t)
+	var tt, zz edwards25519.FieldElement
+	edwards25519.FeMul(&tt, &p.T, &q.T)
+	edwards25519.FeMul(&tt, &tt, &curveD2)
+	edwards25519.FeMul(&zz, &p.Z, &q.Z)
+	edwards25519.FeAdd(&zz, &zz, &zz)
+	edwards25519.FeSub(&c.X, &b, &a)
+	edwards25519.FeAdd(&c.Y, &b, &a)
+	edwards25519.FeAdd(&c.Z, &zz, &tt)
+	edwards25519.FeSub(&c.T, &zz, &tt)
+	r := new(edwards25519.ExtendedGroupElement)
+	c.ToExtended(r)
+	return r
+}
+
+// pointMult returns a*A + b*B, where B is the base point
+func pointMult(a *[32]byte, A *edwards25519.ExtendedGroupElement, b *[32]byte) *edwards25519.ExtendedGroupElement {
+	var p edwards25519.ProjectiveGroupElement
+	edwards25519.GeDoubleScalarMultVartime(&p, a, A, b)
+	r := new(edwards25519.ExtendedGroupElement)
+	edwards25519.FeMul(&r.X, &p.X, &p.Z)
+	edwards25519.FeMul(&r.Y, &p.Y, &p.Z)
+	edwards25519.FeSquare(&r.Z, &p.Z)
+	edwards25519.FeMul(&r.T, &p.X, &p.Y)
+	return r
+}
+
+func isCanonicalScalar(s [32]byte) bool {
+	var wide [64]byte
+	var reduced [32]byte
+	copy(wide[:], s[:])
+	edwards25519.ScReduce(&reduced, &wide)
+	return reduced == s
+}
+
+func isPrimeOrderPoint(p *edwards25519.ExtendedGroupElement) bool {
+	var zero [32]byte
+	r := pointMult(&curveOrder, p, &zero)
+	return bytes.Equal(pointToBytes(r), curveIdentity[:])
+}
+
+// hashToPoint maps the data to a point that nobody knows its discrete logarithm
+func hashToPoint(data []byte) *edwards25519.ExtendedGroupElement {
+	counter := make([]byte, 8)
+	for i := uint64(0); ; i++ {
+		binary.BigEndian.PutUint64(counter, i)
+		h := sha512.Sum512(append(append([]byte{}, data...), counter...))
+		p, err := pointFromBytes(h[:32])
+		if err != nil {
+			continue
+		}
+		// multiplying with the cofactor 8 moves the point to the subgroup of the base point
+		var c edwards25519.CompletedGroupElement
+		for j := 0; j < 3; j++ {
+			p.Double(&c)
+			c.ToExtended(p)
+		}
+		if bytes.Equal(pointToBytes(p), curveIdentity[:]) {
+			continue
+		}
+		return p
+	}
+}
+
+// ringPublicKey returns the raw ed25519 key from the public key's hex
+func ringPublicKey(pubHex string) ([]byte, error) {
+	pubB, err := hex.DecodeString(pubHex)
+	if err != nil {
+		return nil, errors.New("The public key is not correct hex: " + err.Error())
+	}
+	pub, err := crypto.UnmarshalPublicKey(pubB)
+	if err != nil {
+		return nil, errors.New("The public key is not correct")
+	}
+	if _, ok := pub.(*crypto.Ed25519PublicKey); !ok {
+		return nil, errors.New("The public key is not ed25519.")
+	}
+	// the key is marshaled as the protobuf's type and data, and the data are the last 32 bytes
+	return pubB[len(pubB)-32:], nil
+}
+
+// ringSecretKey returns the ed25519 secret scalar from the private key
+func ringSecretKey(privk crypto.PrivKey) ([32]byte, error) {
+	var x [32]byte
+	if _, ok := privk.(*crypto.Ed25519PrivateKey); !ok {
+		return x, errors.New("The private key is not ed25519.")
+	}
+	b, err := privk.Bytes()
+	if err != nil {
+		return x, err
+	}
+	// the key is marshaled as the protobuf's type and data, and the data start with the seed
+	if len(b) < 4 || b[0] != 0x08 || b[2] != 0x12 {
+		return x, errors.New("The private key has not a correct format.")
+	}
+	_, n := binary.Uvarint(b[3:])
+	data := b[3+n:]
+	if n <= 0 || len(data) < 64 {
+		return x, errors.New("The private key has not a correct format.")
+	}
+	h := sha512.Sum512(data[:32])
+	h[0] &= 248
+	h[31] &= 127
+	h[31] |= 64
+	var wide [64]byte
+	copy(wide[:], h[:32])
+	edwards25519.ScReduce(&x, &wide)
+	return x, nil
+}
+
+type ringMember struct {
+	pub  *edwards25519.ExtendedGroupElement
+	hash *edwards25519.ExtendedGroupElement
+}
+
+func newRing(ring []string, scope string) ([]ringMember, [][]byte, error) {
+	members := []ringMember{}
+	raws := [][]byte{}
+	for _, v := range ring {
+		raw, err := ringPublicKey(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		pub, err := pointFromBytes(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		hash := hashToPoint(append(append([]byte{}, raw...), []byte(scope)...))
+		members = append(members, ringMember{pub: pub, hash: hash})
+		raws = append(raws, raw)
+	}
+	return members, raws, nil
+}
+
+func ringChallenge(prefix [32]byte, L, R *edwards25519.ExtendedGroupElement) [32]byte {
+	return ringHashToScalar(prefix[:], pointToBytes(L), pointToBytes(R))
+}
+
+// RingKeyImage returns the key image of the private key for the scope, as hex
+func RingKeyImage(privk crypto.PrivKey, scope string) (string, error) {
+	x, err := ringSecretKey(privk)
+	if err != nil {
+		return "", err
+	}
+	pubB, _ := privk.GetPublic().Bytes()
+	raw, err := ringPublicKey(hex.EncodeToString(pubB))
+	if err != nil {
+		return "", err
+	}
+	var zero [32]byte
+	hash := hashToPoint(append(append([]byte{}, raw...), []byte(scope)...))
+	return hex.EncodeToString(pointToBytes(pointMult(&x, hash, &zero))), nil
+}
+
+// SignRing signs the message for the ring of public keys in hex,
+// and the private key's public key should be in the ring.
+// The signature is the first challenge followed by a response for each member of the ring.
+func SignRing(privk crypto.PrivKey, ring []string, scope string, msg []byte) ([]byte, error) {
+	x, err := ringSecretKey(privk)
+	if err != nil {
+		return nil, err
+	}
+	pubB, _ := privk.GetPublic().Bytes()
+	pubHex := hex.EncodeToString(pubB)
+	signer := -1
+	for i, v := range ring {
+		if v == pubHex {
+			signer = i
+		}
+	}
+	if signer < 0 {
+		return nil, errors.New("The public key is not in the ring.")
+	}
+	members, raws, err := newRing(ring, scope)
+	if err != nil {
+		return nil, err
+	}
+	var zero [32]byte
+	image := pointMult(&x, members[signer].hash, &zero)
+	prefix := ringHashToScalar(append(append(raws, pointToBytes(image)), msg)...)
+
+	n := len(members)
+	cs := make([][32]byte, n)
+	ss := make([][32]byte, n)
+	alpha, err := ringRandomScalar()
+	if err != nil {
+		return nil, err
+	}
+	L := pointMult(&zero, members[signer].pub, &alpha)
+	R := pointMult(&alpha, members[signer].hash, &zero)
+	cs[(signer+1)%n] = ringChallenge(prefix, L, R)
+	for i := (signer + 1) % n; i != signer; i = (i + 1) % n {
+		ss[i], err = ringRandomScalar()
+		if err != nil {
+			return nil, err
+		}
+		L = pointMult(&cs[i], members[i].pub, &ss[i])
+		R = pointAdd(pointMult(&ss[i], members[i].hash, &zero), pointMult(&cs[i], image, &zero))
+		cs[(i+1)%n] = ringChallenge(prefix, L, R)
+	}
+	// s = alpha - c*x
+	var negC [32]byte
+	edwards25519.ScMulAdd(&negC, &cs[signer], &curveMinusOne, &zero)
+	edwards25519.ScMulAdd(&ss[signer], &negC, &x, &alpha)
+
+	sig := append([]byte{}, cs[0][:]...)
+	for _, v := range ss {
+		sig = append(sig, v[:]...)
+	}
+	return sig, nil
+}
+
+// VerifyRing checks that the signature was made by a member of the ring with the key image in hex
+func VerifyRing(ring []string, scope string, keyImageHex string, msg []byte, sig []byte) error {
+	if len(ring) == 0 {
+		return errors.New("The ring is empty.")
+	}
+	if len(sig) != 32*(len(ring)+1) {
+		return errors.New("The ring signature has not the correct size.")
+	}
+	imageB, err := hex.DecodeString(keyImageHex)
+	if err != nil {
+		return errors.New("The key image is not correct hex: " + err.Error())
+	}
+	image, err := pointFromBytes(imageB)
+	if err != nil {
+		return errors.New("The key image is not correct: " + err.Error())
+	}
+	// the key image marks the vote, so it should have only one encoding
+	if hex.EncodeToString(pointToBytes(image)) != keyImageHex {
+		return errors.New("The key image is not in the canonical lowercase hex.")
+	}
+	// the key image with a small order component would be different for the same voter
+	if !isPrimeOrderPoint(image) || bytes.Equal(imageB, curveIdentity[:]) {
+		return errors.New("The key image is not in the subgroup of the base point.")
+	}
+	members, raws, err := newRing(ring, scope)
+	if err != nil {
+		return err
+	}
+	prefix := ringHashToScalar(append(append(raws, pointToBytes(image)), msg)...)
+
+	var zero, c0, c [32]byte
+	copy(c0[:], sig[:32])
+	if !isCanonicalScalar(c0) {
+		return errors.New("The ring signature's challenge is not canonical.")
+	}
+	c = c0
+	for i, v := range members {
+		var s [32]byte
+		copy(s[:], sig[32*(i+1):32*(i+2)])
+		if !isCanonicalScalar(s) {
+			return errors.New("The ring signature's response is not canonical.")
+		}
+		L := pointMult(&c, v.pub, &s)
+		R := pointAdd(pointMult(&s, v.hash, &zero), pointMult(&c, image, &zero))
+		c = ringChallenge(prefix, L, R)
+	}
+	if c != c0 {
+		return errors.New("The ring signature does not verify.")
+	}
+	return nil
+}
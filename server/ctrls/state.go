@@ -3,6 +3,9 @@ package ctrls
 import (
 	"encoding/json"
 	"errors"
+	"sort"
+	"strconv"
+	"strings"
 
 	dbm "github.com/tendermint/tmlibs/db"
 )
@@ -12,8 +15,17 @@ var (
 	electionKey         = []byte("election:")
 	pollKey             = []byte("poll:")
 	voteKey             = []byte("vote:")
+	credentialKey       = []byte("credential:")
 	currentElectionsKey = []byte("currentElections")
 	currentPollsKey     = []byte("currentPolls")
+	initiativeKey       = []byte("initiative:")
+	initiativesKey      = []byte("initiatives")
+	registrationKey     = []byte("registration:")
+	registrationsKey    = []byte("registrations:")
+	pollEndsKey         = []byte("pollEnds:")
+	voterElectionsKey   = []byte("voterElections:")
+	rootElectionsKey    = []byte("rootElections")
+	scheduledPollsKey   = []byte("scheduledPolls")
 	latestElectionKey   = []byte("latestElection")
 	latestPollKey       = []byte("latestPoll")
 )
@@ -33,16 +45,246 @@ func prefixVote(vd VoteDeliveryData) []byte {
 	return append(voteKey, b...)
 }
 
+func prefixPollEnds(height int64) []byte {
+	b := []byte(strconv.FormatInt(height, 10))
+	return append(pollEndsKey, b...)
+}
+
+func prefixInitiative(hash string) []byte {
+	b := []byte(hash)
+	return append(initiativeKey, b...)
+}
+
+func prefixRegistration(electionID, voter string) []byte {
+	b := []byte(electionID + "-" + voter)
+	return append(registrationKey, b...)
+}
+
+func prefixRegistrations(electionID string) []byte {
+	b := []byte(electionID)
+	return append(registrationsKey, b...)
+}
+
+func prefixVoterElections(voter string) []byte {
+	b := []byte(voter)
+	return append(voterElectionsKey, b...)
+}
+
+func prefixCredential(electionID, voter string) []byte {
+	b := []byte(electionID + "-" + voter)
+	return append(credentialKey, b...)
+}
+
 type State struct {
 	db      dbm.DB
 	Size    int64  `json:"size"`
 	Height  int64  `json:"height"`
+	Time    int64  `json:"time"`
 	AppHash []byte `json:"app_hash"`
 }
 
+type ElectionStatus string
+
+// The election's lifecycle is draft, open, closed and archived, and it moves only forward.
+// The voters change only in draft, the polls are added and voted only when it is open,
+// the encrypted polls can be decrypted when it is open or closed, and nothing changes when it is archived.
+const (
+	ELECTION_DRAFT    = ElectionStatus("draft")
+	ELECTION_OPEN     = ElectionStatus("open")
+	ELECTION_CLOSED   = ElectionStatus("closed")
+	ELECTION_ARCHIVED = ElectionStatus("archived")
+)
+
+var nextElectionStatus = map[ElectionStatus]ElectionStatus{
+	ELECTION_DRAFT:  ELECTION_OPEN,
+	ELECTION_OPEN:   ELECTION_CLOSED,
+	ELECTION_CLOSED: ELECTION_ARCHIVED,
+}
+
+func (es ElectionStatus) CanChangeTo(next ElectionStatus) bool {
+	n, ok := nextElectionStatus[es]
+	return ok && n == next
+}
+
+type InvitationCommitment struct {
+	Commitment string
+	Height     int64
+}
+
 type ElectionState struct {
-	ID     string
-	Voters []string
+	ID         string
+	Voters     []string
+	Encryption *ElectionEncryption
+	Anonymous  bool
+
+	Credentials *CredentialKey
+
+	VotersRoot  string
+	VotersCount int
+
+	Amendments []VotersAmendment
+
+	Status ElectionStatus
+
+	// the groups of each voter, like a district or a department
+	Groups map[string][]string `json:",omitempty"`
+
+	Paused *PauseState `json:",omitempty"`
+
+	// the percentage of the voters that should support a voter's initiative, so it becomes a poll,
+	// and when it is zero the voters can not propose polls
+	InitiativeShare int `json:",omitempty"`
+
+	// the invitations by the hash of their code, with the voter that redeemed each one,
+	// or empty when it is not spent
+	Invitations map[string]string `json:",omitempty"`
+
+	// the commitments to the invitations' codes by the key that will redeem each one
+	InvitationCommitments map[string]InvitationCommitment `json:",omitempty"`
+
+	Auditors []string `json:",omitempty"`
+
+	// the number of the credentials that the gonverment signed, so the credential votes
+	// of a poll can not be more than them
+	IssuedCredentials int `json:",omitempty"`
+
+	Name          string
+	Description   string
+	Jurisdiction  string
+	CreatedHeight int64
+	CreatedTime   int64
+	Creator       string
+	Polls         []string
+}
+
+func (es *ElectionState) IsAuditor(pubHex string) bool {
+	for _, v := range es.Auditors {
+		if v == pubHex {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingInvitations returns the number of the invitations that are not spent
+func (es *ElectionState) PendingInvitations() int {
+	n := 0
+	for _, v := range es.Invitations {
+		if len(v) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// ValidateInvitationCommitment checks that the voter committed to the code, and that no other key committed to it earlier,
+// because a key that copied the code from the voter's redemption in the mempool commits after the voter
+func (es *ElectionState) ValidateInvitationCommitment(voter, code string) error {
+	ic, ok := es.InvitationCommitments[voter]
+	if !ok {
+		return errors.New("You have not committed to an invitation's code.")
+	}
+	if ic.Commitment != InvitationCommitmentHash(code, voter) {
+		return errors.New("The invitation's code does not match your commitment.")
+	}
+	for k, v := range es.InvitationCommitments {
+		if k != voter && v.Height < ic.Height && v.Commitment == InvitationCommitmentHash(code, k) {
+			return errors.New("Another key has committed to the invitation's code earlier.")
+		}
+	}
+	return nil
+}
+
+func (es *ElectionState) NumberOfVoters() int {
+	if len(es.VotersRoot) > 0 {
+		return es.VotersCount
+	}
+	return len(es.Voters)
+}
+
+// VoterGroups returns the voter's groups, and only the poll's groups when the poll has groups
+func (es *ElectionState) VoterGroups(pubHex string, pollGroups []string) []string {
+	if len(pollGroups) == 0 {
+		return es.Groups[pubHex]
+	}
+	groups := []string{}
+	for _, g := range es.Groups[pubHex] {
+		for _, pg := range pollGroups {
+			if g == pg {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return groups
+}
+
+func (es *ElectionState) HasGroup(group string) bool {
+	for _, groups := range es.Groups {
+		for _, g := range groups {
+			if g == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (es *ElectionState) ValidateStatus(allowed ...ElectionStatus) error {
+	for _, v := range allowed {
+		if es.Status == v {
+			return nil
+		}
+	}
+	return errors.New("The election is " + string(es.Status) + ".")
+}
+
+// PauseState has the reason and the height of the block that the votes paused
+type PauseState struct {
+	Reason string
+	Height int64
+}
+
+// CancelState has the reason, the replacement poll and the height of the block that the poll cancelled
+type CancelState struct {
+	Reason      string
+	Replacement string `json:",omitempty"`
+	Height      int64
+}
+
+// ValidateNotPaused checks that the votes are not paused for the election or the poll
+func ValidateNotPaused(es *ElectionState, ps *PollState) error {
+	if es.Paused != nil {
+		return errors.New("The election is paused: " + es.Paused.Reason)
+	}
+	if ps.Paused != nil {
+		return errors.New("The poll is paused: " + ps.Paused.Reason)
+	}
+	return nil
+}
+
+// VotersAmendment is a change on the election's voters at the height of the block,
+// so the voters that could vote at any height can be found from the amendments.
+type VotersAmendment struct {
+	Height  int64
+	Added   []string `json:",omitempty"`
+	Removed []string `json:",omitempty"`
+}
+
+// IsVoter checks the voter with the membership proof, when the election has the voters' root
+func (es *ElectionState) IsVoter(pubHex string, proof MerkleProof) bool {
+	if len(es.VotersRoot) > 0 {
+		return VerifyMerkleProof(es.VotersRoot, pubHex, proof) == nil
+	}
+	return es.HasVoter(pubHex)
+}
+
+func (es *ElectionState) HasVoter(pubHex string) bool {
+	for _, v := range es.Voters {
+		if v == pubHex {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *State) GetElection(uuid string) (*ElectionState, error) {
@@ -63,18 +305,468 @@ func (s *State) CreateElection(ed ElectionDeliveryData) {
 	es := ElectionState{}
 	es.ID = ed.ID
 	es.Voters = ed.Voters
+	es.Encryption = ed.Encryption
+	es.Anonymous = ed.Anonymous
+	es.Credentials = ed.Credentials
+	// the Merkle proofs compare the root in lowercase hex
+	es.VotersRoot = strings.ToLower(ed.VotersRoot)
+	es.VotersCount = ed.VotersCount
+	es.Groups = ed.Groups
+	es.InitiativeShare = ed.InitiativeShare
+	if len(ed.Invitations) > 0 {
+		es.Invitations = map[string]string{}
+		for _, v := range ed.Invitations {
+			es.Invitations[v] = ""
+		}
+	}
+	es.Auditors = ed.Auditors
+	es.Status = ELECTION_OPEN
+	if ed.Draft {
+		es.Status = ELECTION_DRAFT
+	}
+	es.Name = ed.Name
+	es.Description = ed.Description
+	es.Jurisdiction = ed.Jurisdiction
+	es.CreatedHeight = s.Height
+	es.CreatedTime = s.Time
+	es.Creator = ed.From
+	es.Polls = []string{}
 	b, _ := json.Marshal(es)
 	s.db.Set(prefixElection(es.ID), b)
 	s.db.Set(latestElectionKey, []byte(es.ID))
+	for _, v := range es.Voters {
+		s.addVoterElection(v, es.ID)
+	}
+	if len(es.VotersRoot) > 0 {
+		ids := s.GetVotersRootElections()
+		b, _ := json.Marshal(append(ids, es.ID))
+		s.db.Set(rootElectionsKey, b)
+	}
+
+	curElsB := s.db.Get(currentElectionsKey)
+	curEls := []ElectionQuery{}
+	json.Unmarshal(curElsB, &curEls)
+	curEls = append(curEls, ElectionQuery{ID: ed.ID, NumberOfVoters: ed.NumberOfVoters(), Status: es.Status})
+	curElsBRes, _ := json.Marshal(curEls)
+	s.db.Set(currentElectionsKey, curElsBRes)
+}
+
+func (s *State) AddVotersToTheElection(vd VotersDeliveryData) error {
+	es, err := s.GetElection(vd.ElectionID)
+	if err != nil {
+		return err
+	}
+	es.Voters = append(es.Voters, vd.Voters...)
+	for _, v := range vd.Voters {
+		s.addVoterElection(v, es.ID)
+	}
+	for k, v := range vd.Groups {
+		if es.Groups == nil {
+			es.Groups = map[string][]string{}
+		}
+		es.Groups[k] = v
+	}
+	es.Amendments = append(es.Amendments, VotersAmendment{Height: s.Height, Added: vd.Voters})
+	s.updateElection(es)
+	return nil
+}
+
+func (s *State) RemoveVotersFromTheElection(vd VotersDeliveryData) error {
+	es, err := s.GetElection(vd.ElectionID)
+	if err != nil {
+		return err
+	}
+	removed := map[string]bool{}
+	for _, v := range vd.Voters {
+		removed[v] = true
+		delete(es.Groups, v)
+		s.removeVoterElection(v, es.ID)
+	}
+	voters := []string{}
+	for _, v := range es.Voters {
+		if !removed[v] {
+			voters = append(voters, v)
+		}
+	}
+	es.Voters = voters
+	es.Amendments = append(es.Amendments, VotersAmendment{Height: s.Height, Removed: vd.Voters})
+	s.updateElection(es)
+	return nil
+}
+
+// ReplaceVoterKey swaps the voter's key, and the new key has voted in the polls that the old key voted,
+// so the voter can not vote twice. The credential's request moves to the new key too.
+func (s *State) ReplaceVoterKey(rd ReplaceKeyDeliveryData) error {
+	es, err := s.GetElection(rd.ElectionID)
+	if err != nil {
+		return err
+	}
+	for i, v := range es.Voters {
+		if v == rd.OldVoter {
+			es.Voters[i] = rd.NewVoter
+		}
+	}
+	s.removeVoterElection(rd.OldVoter, es.ID)
+	s.addVoterElection(rd.NewVoter, es.ID)
+	groups, ok := es.Groups[rd.OldVoter]
+	if ok {
+		delete(es.Groups, rd.OldVoter)
+		es.Groups[rd.NewVoter] = groups
+	}
+	for _, p := range es.Polls {
+		old := VoteDeliveryData{From: rd.OldVoter, PollHash: p}
+		if s.HasVote(old) {
+			s.db.Set(prefixVote(VoteDeliveryData{From: rd.NewVoter, PollHash: p}), s.db.Get(prefixVote(old)))
+		}
+	}
+	cs, err := s.GetCredential(rd.ElectionID, rd.OldVoter)
+	if err == nil {
+		cs.Voter = rd.NewVoter
+		b, _ := json.Marshal(cs)
+		s.db.Set(prefixCredential(cs.ElectionID, cs.Voter), b)
+		s.db.Delete(prefixCredential(rd.ElectionID, rd.OldVoter))
+	}
+	es.Amendments = append(es.Amendments, VotersAmendment{Height: s.Height, Added: []string{rd.NewVoter}, Removed: []string{rd.OldVoter}})
+	s.updateElection(es)
+	return nil
+}
+
+func (s *State) RedeemInvitation(rd RedeemInvitationDeliveryData) error {
+	es, err := s.GetElection(rd.ElectionID)
+	if err != nil {
+		return err
+	}
+	es.Invitations[InvitationHash(rd.Code)] = rd.From
+	delete(es.InvitationCommitments, rd.From)
+	es.Voters = append(es.Voters, rd.From)
+	s.addVoterElection(rd.From, es.ID)
+	es.Amendments = append(es.Amendments, VotersAmendment{Height: s.Height, Added: []string{rd.From}})
+	s.updateElection(es)
+	return nil
+}
+
+// CommitInvitation keeps the voter's commitment with the height, and a new commitment replaces the old one
+func (s *State) CommitInvitation(cd CommitInvitationDeliveryData) error {
+	es, err := s.GetElection(cd.ElectionID)
+	if err != nil {
+		return err
+	}
+	if es.InvitationCommitments == nil {
+		es.InvitationCommitments = map[string]InvitationCommitment{}
+	}
+	es.InvitationCommitments[cd.From] = InvitationCommitment{Commitment: cd.Commitment, Height: s.Height}
+	s.updateElection(es)
+	return nil
+}
+
+func (s *State) AddKeyDealing(kd KeyDealingDeliveryData) error {
+	es, err := s.GetElection(kd.ElectionID)
+	if err != nil {
+		return err
+	}
+	for i, v := range es.Encryption.Trustees {
+		if v.PublicKey == kd.From {
+			d := kd.Dealing
+			d.Height = s.Height
+			d.Disqualified = false
+			es.Encryption.Trustees[i].Dealing = &d
+		}
+	}
+	s.updateElection(es)
+	return nil
+}
+
+func (s *State) DisqualifyKeyDealing(kc KeyComplaintDeliveryData) error {
+	es, err := s.GetElection(kc.ElectionID)
+	if err != nil {
+		return err
+	}
+	for i, v := range es.Encryption.Trustees {
+		if v.PublicKey == kc.Dealer && v.Dealing != nil {
+			es.Encryption.Trustees[i].Dealing.Disqualified = true
+		}
+	}
+	s.updateElection(es)
+	return nil
+}
+
+func (s *State) CancelPoll(cd CancelPollDeliveryData) error {
+	ps, err := s.GetPoll(cd.PollHash)
+	if err != nil {
+		return err
+	}
+	ps.Cancelled = &CancelState{Reason: cd.Reason, Replacement: cd.Replacement, Height: s.Height}
+	b, _ := json.Marshal(ps)
+	s.db.Set(prefixPoll(ps.PollHash), b)
+	return nil
+}
+
+// InitiativeState is a poll that a voter proposed, and it becomes a poll when it has enough supporters
+type InitiativeState struct {
+	PollHash   string
+	ElectionID string
+	Proposer   string
+	Supporters []string
+	Height     int64
+	Created    bool
+}
+
+func (s *State) GetInitiative(hash string) (*InitiativeState, error) {
+	has := s.db.Has(prefixInitiative(hash))
+	if !has {
+		return nil, errors.New("Could not find the initiative " + hash + ".")
+	}
+	b := s.db.Get(prefixInitiative(hash))
+	is := InitiativeState{}
+	err := json.Unmarshal(b, &is)
+	if err != nil {
+		return nil, errors.New("The initiative " + hash + " didnt have a correct json format: " + err.Error())
+	}
+	return &is, nil
+}
+
+func (s *State) GetInitiatives() []string {
+	b := s.db.Get(initiativesKey)
+	list := []string{}
+	json.Unmarshal(b, &list)
+	return list
+}
+
+func (s *State) CreateInitiative(id InitiativeDeliveryData) error {
+	is := InitiativeState{PollHash: id.PollHash, ElectionID: id.ElectionID, Proposer: id.From, Height: s.Height}
+	err := s.supportInitiative(&is, id.From)
+	if err != nil {
+		return err
+	}
+	list := s.GetInitiatives()
+	list = append(list, is.PollHash)
+	b, _ := json.Marshal(list)
+	s.db.Set(initiativesKey, b)
+	return nil
+}
+
+func (s *State) SupportInitiative(sd SupportDeliveryData) error {
+	is, err := s.GetInitiative(sd.PollHash)
+	if err != nil {
+		return err
+	}
+	return s.supportInitiative(is, sd.From)
+}
+
+// supportInitiative adds the supporter, and creates the poll when the supporters reach the election's share.
+// The initiative is not saved when the poll could not be created.
+func (s *State) supportInitiative(is *InitiativeState, supporter string) error {
+	is.Supporters = append(is.Supporters, supporter)
+	es, err := s.GetElection(is.ElectionID)
+	if err == nil && !is.Created && is.HasEnoughSupporters(es) {
+		err = s.CreatePoll(PollDeliveryData{From: is.Proposer, ElectionID: is.ElectionID, PollHash: is.PollHash})
+		if err != nil {
+			return err
+		}
+		is.Created = true
+	}
+	b, _ := json.Marshal(is)
+	s.db.Set(prefixInitiative(is.PollHash), b)
+	return nil
+}
+
+// NeededSupporters returns the number of supporters for the election's share of the voters
+func (is *InitiativeState) NeededSupporters(es *ElectionState) int {
+	return (es.NumberOfVoters()*es.InitiativeShare + 99) / 100
+}
+
+func (is *InitiativeState) HasEnoughSupporters(es *ElectionState) bool {
+	return len(is.Supporters) >= is.NeededSupporters(es)
+}
+
+func (is *InitiativeState) HasSupporter(pubHex string) bool {
+	for _, v := range is.Supporters {
+		if v == pubHex {
+			return true
+		}
+	}
+	return false
+}
+
+type RegistrationStatus string
+
+const (
+	REGISTRATION_PENDING  = RegistrationStatus("pending")
+	REGISTRATION_APPROVED = RegistrationStatus("approved")
+	REGISTRATION_REJECTED = RegistrationStatus("rejected")
+)
+
+// RegistrationState is the voter's request to be in the election, and the gonverment's decision
+type RegistrationState struct {
+	ElectionID     string
+	Voter          string
+	Attributes     map[string]string `json:",omitempty"`
+	Height         int64
+	Status         RegistrationStatus
+	Reason         string `json:",omitempty"`
+	DecisionHeight int64  `json:",omitempty"`
+}
+
+func (s *State) GetRegistration(electionID, voter string) (*RegistrationState, error) {
+	has := s.db.Has(prefixRegistration(electionID, voter))
+	if !has {
+		return nil, errors.New("Could not find the registration of the voter " + voter + ".")
+	}
+	b := s.db.Get(prefixRegistration(electionID, voter))
+	rs := RegistrationState{}
+	err := json.Unmarshal(b, &rs)
+	if err != nil {
+		return nil, errors.New("The registration of the voter " + voter + " didnt have a correct json format: " + err.Error())
+	}
+	return &rs, nil
+}
+
+// GetRegistrations returns the voters that requested to be in the election, in the order of their requests
+func (s *State) GetRegistrations(electionID string) []string {
+	b := s.db.Get(prefixRegistrations(electionID))
+	list := []string{}
+	json.Unmarshal(b, &list)
+	return list
+}
+
+func (s *State) setRegistration(rs *RegistrationState) {
+	b, _ := json.Marshal(rs)
+	s.db.Set(prefixRegistration(rs.ElectionID, rs.Voter), b)
+}
+
+// RequestRegistration saves the request as pending, and the rejected voter can request again
+func (s *State) RequestRegistration(rd RegistrationDeliveryData) {
+	_, err := s.GetRegistration(rd.ElectionID, rd.From)
+	if err != nil {
+		list := s.GetRegistrations(rd.ElectionID)
+		list = append(list, rd.From)
+		b, _ := json.Marshal(list)
+		s.db.Set(prefixRegistrations(rd.ElectionID), b)
+	}
+	rs := RegistrationState{ElectionID: rd.ElectionID, Voter: rd.From, Attributes: rd.Attributes, Height: s.Height,
+		Status: REGISTRATION_PENDING}
+	s.setRegistration(&rs)
+}
+
+func (s *State) DecideRegistration(rd RegistrationDecisionDeliveryData, approve bool) error {
+	rs, err := s.GetRegistration(rd.ElectionID, rd.Voter)
+	if err != nil {
+		return err
+	}
+	rs.DecisionHeight = s.Height
+	if approve {
+		rs.Status = REGISTRATION_APPROVED
+		err = s.AddVotersToTheElection(VotersDeliveryData{From: rd.From, ElectionID: rd.ElectionID, Voters: []string{rd.Voter}})
+		if err != nil {
+			return err
+		}
+	} else {
+		rs.Status = REGISTRATION_REJECTED
+		rs.Reason = rd.Reason
+	}
+	s.setRegistration(rs)
+	return nil
+}
+
+func (s *State) ChangePause(pd PauseDeliveryData, pause bool) error {
+	var paused *PauseState
+	if pause {
+		paused = &PauseState{Reason: pd.Reason, Height: s.Height}
+	}
+	if len(pd.PollHash) > 0 {
+		ps, err := s.GetPoll(pd.PollHash)
+		if err != nil {
+			return err
+		}
+		ps.Paused = paused
+		b, _ := json.Marshal(ps)
+		s.db.Set(prefixPoll(ps.PollHash), b)
+		return nil
+	}
+	es, err := s.GetElection(pd.ElectionID)
+	if err != nil {
+		return err
+	}
+	es.Paused = paused
+	s.updateElection(es)
+	return nil
+}
+
+func (s *State) ChangeElectionStatus(esd ElectionStatusDeliveryData) error {
+	es, err := s.GetElection(esd.ElectionID)
+	if err != nil {
+		return err
+	}
+	es.Status = esd.Status
+	s.updateElection(es)
+	return nil
+}
+
+// updateElection saves the election and its number of voters in the list of elections
+func (s *State) updateElection(es *ElectionState) {
+	b, _ := json.Marshal(es)
+	s.db.Set(prefixElection(es.ID), b)
 
 	curElsB := s.db.Get(currentElectionsKey)
 	curEls := []ElectionQuery{}
 	json.Unmarshal(curElsB, &curEls)
-	curEls = append(curEls, ElectionQuery{ID: ed.ID, NumberOfVoters: len(ed.Voters)})
+	for i, v := range curEls {
+		if v.ID == es.ID {
+			curEls[i].NumberOfVoters = es.NumberOfVoters()
+			curEls[i].Status = es.Status
+		}
+	}
 	curElsBRes, _ := json.Marshal(curEls)
 	s.db.Set(currentElectionsKey, curElsBRes)
 }
 
+// GetVotersRootElections returns the elections with the voters' root from their index,
+// because the voters' index does not have their voters
+func (s *State) GetVotersRootElections() []string {
+	b := s.db.Get(rootElectionsKey)
+	ids := []string{}
+	json.Unmarshal(b, &ids)
+	return ids
+}
+
+// GetVoterElections returns the elections that have the voter in their list of voters,
+// from the voter's index, so the elections are not scanned
+func (s *State) GetVoterElections(voter string) []string {
+	b := s.db.Get(prefixVoterElections(voter))
+	ids := []string{}
+	json.Unmarshal(b, &ids)
+	return ids
+}
+
+func (s *State) setVoterElections(voter string, ids []string) {
+	if len(ids) == 0 {
+		s.db.Delete(prefixVoterElections(voter))
+		return
+	}
+	b, _ := json.Marshal(ids)
+	s.db.Set(prefixVoterElections(voter), b)
+}
+
+func (s *State) addVoterElection(voter, electionID string) {
+	ids := s.GetVoterElections(voter)
+	for _, v := range ids {
+		if v == electionID {
+			return
+		}
+	}
+	s.setVoterElections(voter, append(ids, electionID))
+}
+
+func (s *State) removeVoterElection(voter, electionID string) {
+	ids := []string{}
+	for _, v := range s.GetVoterElections(voter) {
+		if v != electionID {
+			ids = append(ids, v)
+		}
+	}
+	s.setVoterElections(voter, ids)
+}
+
 func (s *State) GetElections() []ElectionQuery {
 	curElsB := s.db.Get(currentElectionsKey)
 	curEls := []ElectionQuery{}
@@ -100,6 +792,154 @@ type PollState struct {
 	PollHash     string
 	VotedAlready []string
 	Choices      map[string]int
+
+	// the encrypted polls sum the ballots in EncryptedChoices and fill
+	// the Choices only when the threshold of trustees have decrypted them
+	Encrypted        bool
+	EncryptedChoices map[string]Ciphertext
+	DecryptionShares map[string]map[string]DecryptionShare // by the trustee's public key
+	Tallied          bool
+
+	// the anonymous polls have the key images in VotedAlready instead of the voters
+	Anonymous bool
+
+	// the polls with credentials have the voting keys in VotedAlready instead of the voters
+	Credentials bool
+
+	Questions map[string]QuestionState `json:",omitempty"`
+
+	// the write-ins are counted by their normalized text
+	AllowWriteIns bool
+	WriteIns      map[string]int `json:",omitempty"`
+	WriteInMerges []WriteInMerge `json:",omitempty"`
+
+	// for the score method the choices have the sum of the scores,
+	// and for the schulze method the choices have the first preferences
+	Method       VotingMethod
+	ScoreMax     int `json:",omitempty"`
+	VoiceCredits int `json:",omitempty"`
+	// for the budgeting method the choices have the approvals of the projects
+	Costs  map[string]int `json:",omitempty"`
+	Budget int            `json:",omitempty"`
+
+	// only the voters of the groups can vote, when the poll has groups,
+	// and the votes and the choices are counted also for each group
+	Groups       []string                  `json:",omitempty"`
+	GroupVotes   map[string]int            `json:",omitempty"`
+	GroupChoices map[string]map[string]int `json:",omitempty"`
+
+	Paused    *PauseState  `json:",omitempty"`
+	Cancelled *CancelState `json:",omitempty"`
+
+	CreatedHeight int64
+	EndHeight     int64 `json:",omitempty"`
+	// the runoff is the poll that the chain creates, when the poll ends without a choice above the threshold
+	RunoffThreshold int    `json:",omitempty"`
+	Runoff          string `json:",omitempty"`
+	RunoffOf        string `json:",omitempty"`
+
+	// the results of the poll with the embargo show only the turnout until the poll closes,
+	// or until the gonverment or an auditor overrides it
+	Embargo          bool              `json:",omitempty"`
+	EmbargoOverrides []EmbargoOverride `json:",omitempty"`
+
+	// the pairwise has for each two choices the number of voters that prefer the first from the second
+	Pairwise map[string]map[string]int `json:",omitempty"`
+}
+
+type EmbargoOverride struct {
+	From   string
+	Reason string
+	Height int64
+}
+
+type WriteInMerge struct {
+	Height    int64
+	Variants  []string
+	Candidate string
+}
+
+type QuestionState struct {
+	Choices       map[string]int
+	MinSelections int
+	MaxSelections int
+}
+
+// ValidateAnswers checks the ballot of the vote for the poll's method, and the poll can have
+// the choices, the questions or the write-ins
+func (ps *PollState) ValidateAnswers(vd VoteDeliveryData) error {
+	choice, answers, writeIn := vd.Choice, vd.Answers, vd.WriteIn
+	switch ps.Method {
+	case METHOD_SCHULZE:
+		if vd.ballotParts() != 1 || len(vd.Ranking) == 0 {
+			return errors.New("The poll " + ps.PollHash + " has the schulze method, so the vote should have only the ranking.")
+		}
+		return ValidateRanking(ps.Choices, vd.Ranking)
+	case METHOD_SCORE:
+		if vd.ballotParts() != 1 || len(vd.Scores) == 0 {
+			return errors.New("The poll " + ps.PollHash + " has the score method, so the vote should have only the scores.")
+		}
+		return ValidateScores(ps.Choices, vd.Scores, ps.ScoreMax)
+	case METHOD_QUADRATIC:
+		if vd.ballotParts() != 1 || len(vd.QuadraticVotes) == 0 {
+			return errors.New("The poll " + ps.PollHash + " has the quadratic method, so the vote should have only the quadratic votes.")
+		}
+		return ValidateQuadraticVotes(ps.Choices, vd.QuadraticVotes, ps.VoiceCredits)
+	case METHOD_BUDGETING:
+		if vd.ballotParts() != 1 || len(vd.Projects) == 0 {
+			return errors.New("The poll " + ps.PollHash + " has the budgeting method, so the vote should have only the projects.")
+		}
+		return ValidateProjects(ps.Costs, ps.Budget, vd.Projects)
+	}
+	if len(vd.Ranking) > 0 || len(vd.Scores) > 0 || len(vd.QuadraticVotes) > 0 || len(vd.Projects) > 0 {
+		return errors.New("The poll " + ps.PollHash + " has not the schulze, the score, the quadratic or the budgeting method.")
+	}
+	if len(writeIn) > 0 {
+		if !ps.AllowWriteIns {
+			return errors.New("The poll " + ps.PollHash + " does not have write-ins.")
+		}
+		if len(choice) > 0 || len(answers) > 0 {
+			return errors.New("The vote can have the write-in or the choice, but not both.")
+		}
+		return ValidateWriteIn(writeIn)
+	}
+	if len(ps.Questions) == 0 {
+		if len(answers) > 0 {
+			return errors.New("The poll " + ps.PollHash + " does not have questions.")
+		}
+		_, ok := ps.Choices[choice]
+		if !ok {
+			return errors.New("The choice " + choice + " does not exists for poll " + ps.PollHash + ".")
+		}
+		return nil
+	}
+	if len(choice) > 0 {
+		return errors.New("The poll " + ps.PollHash + " has questions, so the vote should have answers.")
+	}
+	if len(answers) != len(ps.Questions) {
+		return errors.New("The vote should answer all the questions of the poll " + ps.PollHash + ".")
+	}
+	for k, v := range answers {
+		q, ok := ps.Questions[k]
+		if !ok {
+			return errors.New("The question " + k + " does not exists for poll " + ps.PollHash + ".")
+		}
+		if len(v) < q.MinSelections || len(v) > q.MaxSelections {
+			return errors.New("The answer for the question " + k + " has not correct number of selections.")
+		}
+		selected := map[string]bool{}
+		for _, c := range v {
+			_, ok := q.Choices[c]
+			if !ok {
+				return errors.New("The choice " + c + " does not exists for question " + k + ".")
+			}
+			if selected[c] {
+				return errors.New("The choice " + c + " is selected twice for question " + k + ".")
+			}
+			selected[c] = true
+		}
+	}
+	return nil
 }
 
 func (s *State) GetPoll(hash string) (*PollState, error) {
@@ -120,17 +960,89 @@ func (s *State) HasPoll(hash string) bool {
 	return s.db.Has(prefixPoll(hash))
 }
 
+// countChoices adds the vote to the choices, for the poll's method
+func countChoices(choices map[string]int, method VotingMethod, vd VoteDeliveryData) {
+	switch method {
+	case METHOD_SCHULZE:
+		// the choices have the first preferences
+		choices[vd.Ranking[0]] += 1
+	case METHOD_SCORE:
+		for k, v := range vd.Scores {
+			choices[k] += v
+		}
+	case METHOD_BUDGETING:
+		for _, v := range vd.Projects {
+			choices[v] += 1
+		}
+	case METHOD_QUADRATIC:
+		// the tally has the votes and not the credits that they cost
+		for k, v := range vd.QuadraticVotes {
+			choices[k] += v
+		}
+	default:
+		choices[vd.Choice] += 1
+	}
+}
+
 func (s *State) AddVoteToThePoll(vd VoteDeliveryData) error {
 	ps, err := s.GetPoll(vd.PollHash)
 	if err != nil {
 		return err
 	}
 	ps.VotedAlready = append(ps.VotedAlready, vd.From)
-	_, ok := ps.Choices[vd.Choice]
-	if !ok {
-		ps.Choices[vd.Choice] = 1
+	if len(vd.WriteIn) > 0 {
+		if ps.WriteIns == nil {
+			ps.WriteIns = map[string]int{}
+		}
+		ps.WriteIns[vd.WriteIn] += 1
+	} else if len(ps.Questions) > 0 {
+		for k, v := range vd.Answers {
+			for _, c := range v {
+				ps.Questions[k].Choices[c] += 1
+			}
+		}
 	} else {
-		ps.Choices[vd.Choice] += 1
+		if ps.Method == METHOD_SCHULZE {
+			AddRankingToPairwise(ps.Pairwise, vd.Ranking)
+		}
+		countChoices(ps.Choices, ps.Method, vd)
+	}
+
+	// the vote counts also for the voter's groups
+	es, err := s.GetElection(ps.ElectionID)
+	if err == nil {
+		for _, g := range es.VoterGroups(vd.From, ps.Groups) {
+			if ps.GroupVotes == nil {
+				ps.GroupVotes = map[string]int{}
+				ps.GroupChoices = map[string]map[string]int{}
+			}
+			ps.GroupVotes[g] += 1
+			if len(vd.WriteIn) == 0 && len(ps.Questions) == 0 {
+				if ps.GroupChoices[g] == nil {
+					ps.GroupChoices[g] = map[string]int{}
+				}
+				countChoices(ps.GroupChoices[g], ps.Method, vd)
+			}
+		}
+	}
+
+	b, _ := json.Marshal(ps)
+	s.db.Set(prefixPoll(vd.PollHash), b)
+	return nil
+}
+
+func (s *State) AddEncryptedVoteToThePoll(vd EncryptedVoteDeliveryData) error {
+	ps, err := s.GetPoll(vd.PollHash)
+	if err != nil {
+		return err
+	}
+	ps.VotedAlready = append(ps.VotedAlready, vd.From)
+	for k, v := range ps.EncryptedChoices {
+		sum, err := v.Add(vd.Ballot[k])
+		if err != nil {
+			return err
+		}
+		ps.EncryptedChoices[k] = sum
 	}
 
 	b, _ := json.Marshal(ps)
@@ -138,16 +1050,134 @@ func (s *State) AddVoteToThePoll(vd VoteDeliveryData) error {
 	return nil
 }
 
-func (s *State) CreatePoll(pd PollDeliveryData) {
+func (s *State) AddDecryptionToThePoll(dd DecryptionDeliveryData) error {
+	ps, err := s.GetPoll(dd.PollHash)
+	if err != nil {
+		return err
+	}
+	es, err := s.GetElection(ps.ElectionID)
+	if err != nil {
+		return err
+	}
+	ps.DecryptionShares[dd.From] = dd.Shares
+	if len(ps.DecryptionShares) >= es.Encryption.Threshold {
+		for k, v := range ps.EncryptedChoices {
+			shares := map[int]DecryptionShare{}
+			for pub, ds := range ps.DecryptionShares {
+				t, _ := es.Encryption.GetTrustee(pub)
+				shares[t.Index] = ds[k]
+			}
+			n, err := es.Encryption.CombineDecryptionShares(v, shares, len(ps.VotedAlready))
+			if err != nil {
+				return err
+			}
+			ps.Choices[k] = n
+		}
+		ps.Tallied = true
+	}
+
+	b, _ := json.Marshal(ps)
+	s.db.Set(prefixPoll(dd.PollHash), b)
+	return nil
+}
+
+func (s *State) MergeWriteIns(md MergeWriteInsDeliveryData) error {
+	ps, err := s.GetPoll(md.PollHash)
+	if err != nil {
+		return err
+	}
+	sum := 0
+	for _, v := range md.Variants {
+		sum += ps.WriteIns[v]
+		delete(ps.WriteIns, v)
+	}
+	_, ok := ps.Choices[md.Candidate]
+	if ok {
+		ps.Choices[md.Candidate] += sum
+	} else {
+		ps.WriteIns[md.Candidate] += sum
+	}
+	ps.WriteInMerges = append(ps.WriteInMerges, WriteInMerge{Height: s.Height, Variants: md.Variants, Candidate: md.Candidate})
+	b, _ := json.Marshal(ps)
+	s.db.Set(prefixPoll(ps.PollHash), b)
+	return nil
+}
+
+func (s *State) CreatePoll(pd PollDeliveryData) error {
+	pj, err := pd.GetPollJsonFromPollHash()
+	if err != nil {
+		return err
+	}
+	s.createPollFromJson(pd, pj)
+	return nil
+}
+
+// createPollFromJson creates the poll from the poll.json that is fetched already, so it does not use the IPFS
+func (s *State) createPollFromJson(pd PollDeliveryData, pj *PollJson) {
 	ps := PollState{}
 	ps.PollHash = pd.PollHash
 	ps.ElectionID = pd.ElectionID
 	ps.VotedAlready = []string{}
 	ps.Choices = map[string]int{}
-	pj, _ := pd.GetPollJsonFromPollHash()
 	for k, _ := range pj.Choices {
 		ps.Choices[k] = 0
 	}
+	ps.AllowWriteIns = pj.WriteIns
+	ps.Method = pj.GetMethod()
+	if ps.Method == METHOD_SCORE {
+		ps.ScoreMax = pj.GetScoreMax()
+	}
+	if ps.Method == METHOD_QUADRATIC {
+		ps.VoiceCredits = pj.GetVoiceCredits()
+	}
+	if ps.Method == METHOD_BUDGETING {
+		ps.Costs = pj.Costs
+		ps.Budget = pj.Budget
+	}
+	ps.Groups = pj.Groups
+	if ps.Method == METHOD_SCHULZE {
+		ps.Pairwise = NewPairwise(ps.Choices)
+	}
+	if len(pj.Questions) > 0 {
+		ps.Questions = map[string]QuestionState{}
+		for k, v := range pj.Questions {
+			qs := QuestionState{Choices: map[string]int{}, MinSelections: v.MinSelections, MaxSelections: v.GetMaxSelections()}
+			for c := range v.Choices {
+				qs.Choices[c] = 0
+			}
+			ps.Questions[k] = qs
+		}
+	}
+	ps.EndHeight = pd.EndHeight
+	ps.RunoffThreshold = pj.RunoffThreshold
+	ps.Embargo = pj.Embargo
+	s.addPoll(&ps)
+}
+
+// addPoll saves the new poll in the election, and the poll becomes the latest
+func (s *State) addPoll(ps *PollState) {
+	ps.CreatedHeight = s.Height
+	es, err := s.GetElection(ps.ElectionID)
+	if err == nil {
+		ps.Anonymous = es.Anonymous
+		ps.Credentials = es.Credentials != nil
+	}
+	if err == nil {
+		// the first poll finishes the election's key, so the trustees can not deal or complain anymore
+		if es.Encryption != nil && !es.Encryption.IsKeyFinished() {
+			es.Encryption.FinishKeyGeneration(s.Height)
+		}
+		es.Polls = append(es.Polls, ps.PollHash)
+		s.updateElection(es)
+	}
+	if err == nil && es.Encryption != nil {
+		ps.Encrypted = true
+		ps.EncryptedChoices = map[string]Ciphertext{}
+		for k := range ps.Choices {
+			ps.EncryptedChoices[k] = EmptyCiphertext()
+		}
+		ps.DecryptionShares = map[string]map[string]DecryptionShare{}
+	}
 	b, _ := json.Marshal(ps)
 	s.db.Set(prefixPoll(ps.PollHash), b)
 	s.db.Set(latestPollKey, []byte(ps.PollHash))
@@ -158,6 +1188,190 @@ func (s *State) CreatePoll(pd PollDeliveryData) {
 	curPls = append(curPls, PollQuery{PollHash: ps.PollHash})
 	curPlsBRes, _ := json.Marshal(curPls)
 	s.db.Set(currentPollsKey, curPlsBRes)
+
+	if ps.EndHeight > 0 {
+		ends := []string{}
+		json.Unmarshal(s.db.Get(prefixPollEnds(ps.EndHeight)), &ends)
+		ends = append(ends, ps.PollHash)
+		b, _ := json.Marshal(ends)
+		s.db.Set(prefixPollEnds(ps.EndHeight), b)
+	}
+}
+
+type ScheduledPollStatus string
+
+const (
+	SCHEDULED_PENDING   = ScheduledPollStatus("pending")
+	SCHEDULED_ACTIVATED = ScheduledPollStatus("activated")
+	SCHEDULED_DROPPED   = ScheduledPollStatus("dropped")
+)
+
+// ScheduledPollState is the poll that waits for its activation, and it is dropped when at the activation
+// its election is not open or not the latest, or the poll's end height has passed.
+// It keeps the poll.json from its delivery, because the activation at the block's beginning can not use the IPFS.
+type ScheduledPollState struct {
+	Poll            PollDeliveryData
+	PollJson        *PollJson
+	Height          int64
+	Status          ScheduledPollStatus
+	ActivatedHeight int64 `json:",omitempty"`
+}
+
+func (s *State) GetScheduledPolls() []ScheduledPollState {
+	b := s.db.Get(scheduledPollsKey)
+	list := []ScheduledPollState{}
+	json.Unmarshal(b, &list)
+	return list
+}
+
+func (s *State) setScheduledPolls(list []ScheduledPollState) {
+	b, _ := json.Marshal(list)
+	s.db.Set(scheduledPollsKey, b)
+}
+
+func (s *State) HasScheduledPoll(hash string) bool {
+	for _, v := range s.GetScheduledPolls() {
+		if v.Poll.PollHash == hash && v.Status == SCHEDULED_PENDING {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) SchedulePoll(pd PollDeliveryData) error {
+	pj, err := pd.GetPollJsonFromPollHash()
+	if err != nil {
+		return err
+	}
+	list := s.GetScheduledPolls()
+	list = append(list, ScheduledPollState{Poll: pd, PollJson: pj, Height: s.Height, Status: SCHEDULED_PENDING})
+	s.setScheduledPolls(list)
+	return nil
+}
+
+// ActivatePolls creates the scheduled polls that reached their activation, in the order that they were scheduled
+func (s *State) ActivatePolls() {
+	list := s.GetScheduledPolls()
+	changed := false
+	for i, v := range list {
+		if v.Status != SCHEDULED_PENDING {
+			continue
+		}
+		if !(v.Poll.ActivationHeight > 0 && s.Height >= v.Poll.ActivationHeight) &&
+			!(v.Poll.ActivationTime > 0 && s.Time >= v.Poll.ActivationTime) {
+			continue
+		}
+		changed = true
+		list[i].ActivatedHeight = s.Height
+		es, err := s.GetElection(v.Poll.ElectionID)
+		if err != nil || es.Status != ELECTION_OPEN || !s.IsLatestElection(es.ID) || s.HasPoll(v.Poll.PollHash) ||
+			(v.Poll.EndHeight != 0 && v.Poll.EndHeight <= s.Height) || v.PollJson == nil {
+			list[i].Status = SCHEDULED_DROPPED
+			continue
+		}
+		list[i].Status = SCHEDULED_ACTIVATED
+		s.createPollFromJson(v.Poll, v.PollJson)
+	}
+	if changed {
+		s.setScheduledPolls(list)
+	}
+}
+
+// IsPollClosed checks that the poll does not accept votes anymore.
+// The poll closes when it ends, when it is cancelled, when a newer poll replaces it or when its election is not open.
+func (s *State) IsPollClosed(ps *PollState) bool {
+	if ps.Cancelled != nil || ps.HasEnded(s.Height) || !s.IsLatestPoll(ps.PollHash) {
+		return true
+	}
+	es, err := s.GetElection(ps.ElectionID)
+	return err != nil || es.Status != ELECTION_OPEN
+}
+
+// IsEmbargoed checks that the poll's results are hidden, while the poll accepts votes.
+// The embargo lifts with the same rule that closes the poll.
+func (s *State) IsEmbargoed(ps *PollState) bool {
+	return ps.Embargo && len(ps.EmbargoOverrides) == 0 && !s.IsPollClosed(ps)
+}
+
+func (s *State) OverrideEmbargo(ed EmbargoOverrideDeliveryData) error {
+	ps, err := s.GetPoll(ed.PollHash)
+	if err != nil {
+		return err
+	}
+	ps.EmbargoOverrides = append(ps.EmbargoOverrides, EmbargoOverride{From: ed.From, Reason: ed.Reason, Height: s.Height})
+	b, _ := json.Marshal(ps)
+	s.db.Set(prefixPoll(ps.PollHash), b)
+	return nil
+}
+
+// HasEnded checks that the poll has an end height and the block is after it
+func (ps *PollState) HasEnded(height int64) bool {
+	return ps.EndHeight > 0 && height > ps.EndHeight
+}
+
+// RunoffPollHash returns the runoff's ID from the poll's hash, so every node creates the same runoff
+func RunoffPollHash(pollHash string) string {
+	return "runoff-" + pollHash
+}
+
+// RunoffChoices returns the two choices with the most votes, when no choice has more than the threshold
+// of the votes. The choices with the same votes are in the order of their IDs.
+func (ps *PollState) RunoffChoices() []string {
+	votes := len(ps.VotedAlready)
+	if ps.RunoffThreshold == 0 || votes == 0 || len(ps.Choices) < 2 {
+		return nil
+	}
+	choices := []string{}
+	for c, v := range ps.Choices {
+		if v*100 > ps.RunoffThreshold*votes {
+			return nil
+		}
+		choices = append(choices, c)
+	}
+	sort.Strings(choices)
+	sort.SliceStable(choices, func(i, j int) bool {
+		return ps.Choices[choices[i]] > ps.Choices[choices[j]]
+	})
+	return choices[:2]
+}
+
+// EndPolls creates the runoffs for the polls that end at the height. The runoff is created only when
+// the poll is still the latest of the open election, and it has the same duration as the poll.
+func (s *State) EndPolls(height int64) {
+	ends := []string{}
+	json.Unmarshal(s.db.Get(prefixPollEnds(height)), &ends)
+	for _, v := range ends {
+		ps, err := s.GetPoll(v)
+		if err != nil || ps.Cancelled != nil || !s.IsLatestPoll(ps.PollHash) {
+			continue
+		}
+		es, err := s.GetElection(ps.ElectionID)
+		if err != nil || es.Status != ELECTION_OPEN {
+			continue
+		}
+		choices := ps.RunoffChoices()
+		if len(choices) == 0 {
+			continue
+		}
+		rs := PollState{}
+		rs.PollHash = RunoffPollHash(ps.PollHash)
+		rs.ElectionID = ps.ElectionID
+		rs.VotedAlready = []string{}
+		rs.Choices = map[string]int{}
+		for _, c := range choices {
+			rs.Choices[c] = 0
+		}
+		rs.Method = METHOD_PLURALITY
+		rs.Groups = ps.Groups
+		rs.Embargo = ps.Embargo
+		rs.RunoffOf = ps.PollHash
+		rs.EndHeight = height + ps.EndHeight - ps.CreatedHeight
+		s.addPoll(&rs)
+
+		ps.Runoff = rs.PollHash
+		b, _ := json.Marshal(ps)
+		s.db.Set(prefixPoll(ps.PollHash), b)
+	}
 }
 
 func (s *State) GetPolls() []PollQuery {
@@ -182,7 +1396,8 @@ func (s *State) IsLatestPoll(id string) bool {
 }
 
 func (s *State) CreateVote(vd VoteDeliveryData) {
-	s.db.Set(prefixVote(vd), nil)
+	// the height of the vote, so it can be compared with the election's amendments
+	s.db.Set(prefixVote(vd), []byte(strconv.FormatInt(s.Height, 10)))
 }
 
 func (s *State) HasVote(vd VoteDeliveryData) bool {
@@ -209,3 +1424,54 @@ func saveState(state State) {
 	}
 	state.db.Set(stateKey, stateBytes)
 }
+
+type CredentialState struct {
+	ElectionID     string
+	Voter          string
+	Blinded        string
+	BlindSignature string
+}
+
+func (s *State) GetCredential(electionID, voter string) (*CredentialState, error) {
+	has := s.db.Has(prefixCredential(electionID, voter))
+	if !has {
+		return nil, errors.New("Could not find the credential's request from " + voter + " for the election " + electionID + ".")
+	}
+	b := s.db.Get(prefixCredential(electionID, voter))
+	cs := CredentialState{}
+	err := json.Unmarshal(b, &cs)
+	if err != nil {
+		return nil, errors.New("The credential didnt have a correct json format: " + err.Error())
+	}
+	return &cs, nil
+}
+
+func (s *State) HasCredential(electionID, voter string) bool {
+	return s.db.Has(prefixCredential(electionID, voter))
+}
+
+func (s *State) CreateCredentialRequest(crd CredentialRequestDeliveryData) {
+	cs := CredentialState{}
+	cs.ElectionID = crd.ElectionID
+	cs.Voter = crd.From
+	cs.Blinded = crd.Blinded
+	b, _ := json.Marshal(cs)
+	s.db.Set(prefixCredential(cs.ElectionID, cs.Voter), b)
+}
+
+func (s *State) AddBlindSignatureToTheCredential(cd CredentialDeliveryData) error {
+	cs, err := s.GetCredential(cd.ElectionID, cd.Voter)
+	if err != nil {
+		return err
+	}
+	es, err := s.GetElection(cd.ElectionID)
+	if err != nil {
+		return err
+	}
+	cs.BlindSignature = cd.BlindSignature
+	b, _ := json.Marshal(cs)
+	s.db.Set(prefixCredential(cs.ElectionID, cs.Voter), b)
+	es.IssuedCredentials++
+	s.updateElection(es)
+	return nil
+}
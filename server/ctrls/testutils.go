@@ -15,12 +15,36 @@ import (
 )
 
 func forTestCreateElection(t *testing.T, app *TVApplication, privk crypto.PrivKey, voters []string) string {
+	return forTestCreateEncryptedElection(t, app, privk, voters, nil)
+}
+
+func forTestCreateEncryptedElection(t *testing.T, app *TVApplication, privk crypto.PrivKey, voters []string, enc *ElectionEncryption) string {
+	return forTestDeliverElection(t, app, privk, ElectionDeliveryData{Voters: voters, Encryption: enc})
+}
+
+func forTestCreateAnonymousElection(t *testing.T, app *TVApplication, privk crypto.PrivKey, voters []string) string {
+	return forTestDeliverElection(t, app, privk, ElectionDeliveryData{Voters: voters, Anonymous: true})
+}
+
+func forTestCreateCredentialElection(t *testing.T, app *TVApplication, privk crypto.PrivKey, voters []string, key *CredentialKey) string {
+	return forTestDeliverElection(t, app, privk, ElectionDeliveryData{Voters: voters, Credentials: key})
+}
+
+func forTestCreateDraftElection(t *testing.T, app *TVApplication, privk crypto.PrivKey, voters []string) string {
+	return forTestDeliverElection(t, app, privk, ElectionDeliveryData{Voters: voters, Draft: true})
+}
+
+func forTestChangeElectionStatus(t *testing.T, app *TVApplication, privk crypto.PrivKey, electionID string, status ElectionStatus) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+	esd := ElectionStatusDeliveryData{From: hex.EncodeToString(pubB), ElectionID: electionID, Status: status}
+	return forTestDeliver(t, app, privk, ELECTION_STATUS, &esd)
+}
+
+func forTestDeliverElection(t *testing.T, app *TVApplication, privk crypto.PrivKey, ed ElectionDeliveryData) string {
 	pubB, _ := privk.GetPublic().Bytes()
 
-	ed := ElectionDeliveryData{}
 	ed.ID = uuid.NewV4().String()
 	ed.From = hex.EncodeToString(pubB)
-	ed.Voters = voters
 
 	b, _ := json.Marshal(ed)
 	sign, err := privk.Sign(b)
@@ -40,18 +64,19 @@ func forTestCreateElection(t *testing.T, app *TVApplication, privk crypto.PrivKe
 }
 
 func forTestCreatePoll(t *testing.T, app *TVApplication, privk crypto.PrivKey, electionID string, choices map[string]string) string {
-	pubB, _ := privk.GetPublic().Bytes()
-	pd := PollDeliveryData{}
-	pd.From = hex.EncodeToString(pubB)
-	pd.ElectionID = electionID
+	hash := forTestUploadPoll(t, choices)
+	assert.Equal(t, CodeTypeOK, forTestDeliverPoll(t, app, privk, electionID, hash))
+	return hash
+}
 
+func forTestUploadPoll(t *testing.T, choices map[string]string) string {
+	return forTestUploadPollJson(t, PollJson{Description: "k", Choices: choices})
+}
+
+func forTestUploadPollJson(t *testing.T, pj PollJson) string {
 	// we will use a temporary folder
 	tmpFolder := "temporary"
 	os.MkdirAll(tmpFolder, 0755)
-	pj := PollJson{
-		Description: "k",
-		Choices:     choices,
-	}
 	bpj, _ := json.Marshal(pj)
 	err := ioutil.WriteFile(tmpFolder+"/poll.json", bpj, 0755)
 	assert.Nil(t, err)
@@ -59,7 +84,14 @@ func forTestCreatePoll(t *testing.T, app *TVApplication, privk crypto.PrivKey, e
 	hash, err := sh.AddDir(tmpFolder)
 	assert.Nil(t, err)
 	os.RemoveAll(tmpFolder)
+	return hash
+}
 
+func forTestDeliverPoll(t *testing.T, app *TVApplication, privk crypto.PrivKey, electionID, hash string) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+	pd := PollDeliveryData{}
+	pd.From = hex.EncodeToString(pubB)
+	pd.ElectionID = electionID
 	pd.PollHash = hash
 	b, _ := json.Marshal(pd)
 	sign, err := privk.Sign(b)
@@ -74,8 +106,7 @@ func forTestCreatePoll(t *testing.T, app *TVApplication, privk crypto.PrivKey, e
 
 	tx, _ := json.Marshal(tvd)
 	resp := app.DeliverTx(tx)
-	assert.Equal(t, CodeTypeOK, resp.Code)
-	return pd.PollHash
+	return resp.Code
 }
 
 func forTestCreateVote(t *testing.T, app *TVApplication, privk crypto.PrivKey, election, poll, choice string) {
@@ -98,3 +129,91 @@ func forTestCreateVote(t *testing.T, app *TVApplication, privk crypto.PrivKey, e
 	resp := app.DeliverTx(tx)
 	assert.Equal(t, CodeTypeOK, resp.Code)
 }
+
+func forTestCreateEncryptedVote(t *testing.T, app *TVApplication, privk crypto.PrivKey, enc *ElectionEncryption, poll string, choices []string, choice string) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+
+	vd := EncryptedVoteDeliveryData{}
+	vd.From = hex.EncodeToString(pubB)
+	vd.PollHash = poll
+	ballot, err := enc.EncryptBallot(BallotContext(vd.From, poll), choices, choice)
+	assert.Nil(t, err)
+	vd.EncryptedBallot = *ballot
+	return forTestDeliverEncryptedVote(t, app, privk, vd)
+}
+
+func forTestDeliverEncryptedVote(t *testing.T, app *TVApplication, privk crypto.PrivKey, vd EncryptedVoteDeliveryData) uint32 {
+	b, _ := json.Marshal(vd)
+	sign, err := privk.Sign(b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = ENCRYPTED_VOTE
+	tvd.Signature = sign
+	tvd.Data = &vd
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	return resp.Code
+}
+
+func forTestCreateDecryption(t *testing.T, app *TVApplication, privk crypto.PrivKey, share TrusteeShare, poll string) uint32 {
+	pubB, _ := privk.GetPublic().Bytes()
+
+	ps, err := app.state.GetPoll(poll)
+	assert.Nil(t, err)
+	dd := DecryptionDeliveryData{}
+	dd.From = hex.EncodeToString(pubB)
+	dd.PollHash = poll
+	dd.Shares, err = share.DecryptChoices(ps.EncryptedChoices)
+	assert.Nil(t, err)
+	b, _ := json.Marshal(dd)
+	sign, err := privk.Sign(b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = DECRYPTION
+	tvd.Signature = sign
+	tvd.Data = &dd
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	return resp.Code
+}
+
+func forTestCreateAnonymousVote(t *testing.T, app *TVApplication, privk crypto.PrivKey, ring []string, poll, choice string) uint32 {
+	keyImage, err := RingKeyImage(privk, poll)
+	assert.Nil(t, err)
+
+	vd := AnonymousVoteDeliveryData{}
+	vd.PollHash = poll
+	vd.Choice = choice
+	vd.KeyImage = keyImage
+	b, _ := json.Marshal(vd)
+	sign, err := SignRing(privk, ring, poll, b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = ANONYMOUS_VOTE
+	tvd.Signature = sign
+	tvd.Data = &vd
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	return resp.Code
+}
+
+func forTestDeliver(t *testing.T, app *TVApplication, privk crypto.PrivKey, dt DeliveryType, data interface{}) uint32 {
+	b, _ := json.Marshal(data)
+	sign, err := privk.Sign(b)
+	assert.Nil(t, err)
+
+	tvd := TVDelivery{}
+	tvd.Type = dt
+	tvd.Signature = sign
+	tvd.Data = data
+
+	tx, _ := json.Marshal(tvd)
+	resp := app.DeliverTx(tx)
+	return resp.Code
+}
@@ -0,0 +1,240 @@
+package ctrls
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// The poll counts the votes with one of the methods.
+// The plurality counts one choice for each vote.
+// The schulze method takes a full ranking of the choices from each vote, and keeps for each two choices
+// how many voters prefer the first from the second. The winners come from the strongest paths between the choices.
+// The score method takes a score for each choice from each vote, and the results are the average scores.
+// The quadratic method gives a budget of voice credits to each voter, and n votes on a choice cost n*n credits,
+// so the voter can show how strong is the preference. The results have the votes and not the credits.
+// The budgeting method has a cost for each choice and a total budget. The vote approves projects that fit in the budget,
+// and the results fund the projects with the most approvals, while they fit in the budget.
+
+type VotingMethod string
+
+const (
+	METHOD_PLURALITY = VotingMethod("plurality")
+	METHOD_SCHULZE   = VotingMethod("schulze")
+	METHOD_SCORE     = VotingMethod("score")
+	METHOD_QUADRATIC = VotingMethod("quadratic")
+	METHOD_BUDGETING = VotingMethod("budgeting")
+)
+
+const (
+	defaultScoreMax     = 5
+	scoreMaxLimit       = 100
+	defaultVoiceCredits = 100
+	voiceCreditsLimit   = 10000
+)
+
+// ValidateRanking checks that the ranking has all the choices once
+func ValidateRanking(choices map[string]int, ranking []string) error {
+	if len(ranking) != len(choices) {
+		return errors.New("The ranking should have all the choices of the poll.")
+	}
+	ranked := map[string]bool{}
+	for _, c := range ranking {
+		_, ok := choices[c]
+		if !ok {
+			return errors.New("The choice " + c + " in the ranking does not exists.")
+		}
+		if ranked[c] {
+			return errors.New("The choice " + c + " is twice in the ranking.")
+		}
+		ranked[c] = true
+	}
+	return nil
+}
+
+// ValidateScores checks that the scores rate all the choices from zero to the max
+func ValidateScores(choices map[string]int, scores map[string]int, max int) error {
+	if len(scores) != len(choices) {
+		return errors.New("The scores should rate all the choices of the poll.")
+	}
+	for c, v := range scores {
+		_, ok := choices[c]
+		if !ok {
+			return errors.New("The choice " + c + " in the scores does not exists.")
+		}
+		if v < 0 || v > max {
+			return errors.New("The score for the choice " + c + " should be from 0 to " + strconv.Itoa(max) + ".")
+		}
+	}
+	return nil
+}
+
+// ValidateQuadraticVotes checks that the votes are for the choices, and that they cost up to the voice credits
+func ValidateQuadraticVotes(choices map[string]int, votes map[string]int, credits int) error {
+	cost := 0
+	for c, v := range votes {
+		_, ok := choices[c]
+		if !ok {
+			return errors.New("The choice " + c + " in the quadratic votes does not exists.")
+		}
+		if v < 0 || v > credits {
+			return errors.New("The votes for the choice " + c + " should be from 0 to " + strconv.Itoa(credits) + ".")
+		}
+		cost += v * v
+		if cost > credits {
+			return errors.New("The quadratic votes cost more than the " + strconv.Itoa(credits) + " voice credits.")
+		}
+	}
+	return nil
+}
+
+// ValidateCosts checks that each choice has a positive cost, and that the budget is positive
+func ValidateCosts(choices map[string]string, costs map[string]int, budget int) error {
+	if budget <= 0 {
+		return errors.New("The budget should be positive.")
+	}
+	if len(costs) != len(choices) {
+		return errors.New("The costs should be for all the choices of the poll.")
+	}
+	for c, v := range costs {
+		_, ok := choices[c]
+		if !ok {
+			return errors.New("The choice " + c + " in the costs does not exists.")
+		}
+		if v <= 0 {
+			return errors.New("The cost of the choice " + c + " should be positive.")
+		}
+	}
+	return nil
+}
+
+// ValidateProjects checks that the projects are different choices, and that their costs fit in the budget
+func ValidateProjects(costs map[string]int, budget int, projects []string) error {
+	total := 0
+	selected := map[string]bool{}
+	for _, p := range projects {
+		cost, ok := costs[p]
+		if !ok {
+			return errors.New("The project " + p + " does not exists.")
+		}
+		if selected[p] {
+			return errors.New("The project " + p + " is selected twice.")
+		}
+		selected[p] = true
+		total += cost
+		if total > budget {
+			return errors.New("The projects cost more than the budget " + strconv.Itoa(budget) + ".")
+		}
+	}
+	return nil
+}
+
+// FundedProjects returns the projects from the most approved, and a project is skipped when it does not fit
+// in the rest of the budget. The projects with the same approvals are in the order of their IDs.
+func FundedProjects(approvals map[string]int, costs map[string]int, budget int) []string {
+	projects := []string{}
+	for p := range costs {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	sort.SliceStable(projects, func(i, j int) bool {
+		return approvals[projects[i]] > approvals[projects[j]]
+	})
+	funded := []string{}
+	for _, p := range projects {
+		if approvals[p] > 0 && costs[p] <= budget {
+			funded = append(funded, p)
+			budget -= costs[p]
+		}
+	}
+	return funded
+}
+
+func NewPairwise(choices map[string]int) map[string]map[string]int {
+	pairwise := map[string]map[string]int{}
+	for a := range choices {
+		pairwise[a] = map[string]int{}
+		for b := range choices {
+			if a != b {
+				pairwise[a][b] = 0
+			}
+		}
+	}
+	return pairwise
+}
+
+func AddRankingToPairwise(pairwise map[string]map[string]int, ranking []string) {
+	for i, a := range ranking {
+		for _, b := range ranking[i+1:] {
+			pairwise[a][b] += 1
+		}
+	}
+}
+
+func sortedChoices(pairwise map[string]map[string]int) []string {
+	choices := []string{}
+	for c := range pairwise {
+		choices = append(choices, c)
+	}
+	sort.Strings(choices)
+	return choices
+}
+
+// SchulzeRanking returns the choices from the winner to the last,
+// and the choices with the same wins are in the order of their IDs
+func SchulzeRanking(pairwise map[string]map[string]int) []string {
+	choices := sortedChoices(pairwise)
+	paths := map[string]map[string]int{}
+	for _, a := range choices {
+		paths[a] = map[string]int{}
+		for _, b := range choices {
+			if a != b && pairwise[a][b] > pairwise[b][a] {
+				paths[a][b] = pairwise[a][b]
+			}
+		}
+	}
+	for _, i := range choices {
+		for _, j := range choices {
+			if i == j {
+				continue
+			}
+			for _, k := range choices {
+				if i == k || j == k {
+					continue
+				}
+				through := paths[j][i]
+				if paths[i][k] < through {
+					through = paths[i][k]
+				}
+				if through > paths[j][k] {
+					paths[j][k] = through
+				}
+			}
+		}
+	}
+	wins := map[string]int{}
+	for _, a := range choices {
+		for _, b := range choices {
+			if a != b && paths[a][b] > paths[b][a] {
+				wins[a] += 1
+			}
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool {
+		return wins[choices[i]] > wins[choices[j]]
+	})
+	return choices
+}
+
+// AverageScores returns the average score of each choice from the sum of the scores
+func AverageScores(sums map[string]int, votes int) map[string]float64 {
+	averages := map[string]float64{}
+	for c, v := range sums {
+		if votes == 0 {
+			averages[c] = 0
+		} else {
+			averages[c] = float64(v) / float64(votes)
+		}
+	}
+	return averages
+}